@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+
+	"github.com/fbriansyah/my-grpc-go-client/internal/adapter/bank"
+	dbank "github.com/fbriansyah/my-grpc-go-client/internal/application/domain/bank"
+	"google.golang.org/grpc"
+)
+
+func bankCommands() []command {
+	return []command{
+		{
+			service: "bank", name: "balance", summary: "GetCurrentBalance, unary",
+			setup: func(fs *flag.FlagSet) runFunc {
+				acct := fs.String("account", "", "account number")
+
+				return func(ctx context.Context, conn *grpc.ClientConn) error {
+					if *acct == "" {
+						return errors.New("account number is required, use -account")
+					}
+
+					adapter, err := bank.NewBankAdapter(conn)
+					if err != nil {
+						return err
+					}
+
+					return runGetCurrentBalance(ctx, adapter, *acct)
+				}
+			},
+		},
+		{
+			service: "bank", name: "rates", summary: "FetchExchangeRates, server streaming",
+			setup: func(fs *flag.FlagSet) runFunc {
+				from := fs.String("from", "USD", "currency to convert from")
+				to := fs.String("to", "IDR", "currency to convert to")
+
+				return func(ctx context.Context, conn *grpc.ClientConn) error {
+					adapter, err := bank.NewBankAdapter(conn)
+					if err != nil {
+						return err
+					}
+
+					return runFetchExchangeRates(ctx, adapter, *from, *to)
+				}
+			},
+		},
+		{
+			service: "bank", name: "summarize", summary: "SummarizeTransactions, client streaming",
+			setup: func(fs *flag.FlagSet) runFunc {
+				acct := fs.String("account", "", "account number")
+				var txs transactionsFlag
+				fs.Var(&txs, "tx", "transaction as TYPE:AMOUNT[:NOTES], can be repeated")
+
+				return func(ctx context.Context, conn *grpc.ClientConn) error {
+					if *acct == "" {
+						return errors.New("account number is required, use -account")
+					}
+
+					adapter, err := bank.NewBankAdapter(conn)
+					if err != nil {
+						return err
+					}
+
+					return runSummarizeTransactions(ctx, adapter, *acct, txs)
+				}
+			},
+		},
+		{
+			service: "bank", name: "transfer", summary: "TransferMultiple, bidirectional streaming",
+			setup: func(fs *flag.FlagSet) runFunc {
+				var trfs transfersFlag
+				fs.Var(&trfs, "transfer", "transfer as FROM:TO:CURRENCY:AMOUNT, can be repeated")
+
+				return func(ctx context.Context, conn *grpc.ClientConn) error {
+					if len(trfs) == 0 {
+						return errors.New("at least one transfer is required, use -transfer")
+					}
+
+					adapter, err := bank.NewBankAdapter(conn)
+					if err != nil {
+						return err
+					}
+
+					return runTransferMultiple(ctx, adapter, trfs)
+				}
+			},
+		},
+		{
+			service: "bank", name: "create-account", summary: "CreateAccount, unary",
+			setup: func(fs *flag.FlagSet) runFunc {
+				var acct dbank.CreateAccount
+				fs.StringVar(&acct.AccountName, "name", "", "account holder name")
+				fs.StringVar(&acct.Currency, "currency", "IDR", "3-letter account currency code")
+				fs.Float64Var(&acct.InitialDepositAmount, "deposit", 0, "initial deposit amount")
+
+				return func(ctx context.Context, conn *grpc.ClientConn) error {
+					adapter, err := bank.NewBankAdapter(conn)
+					if err != nil {
+						return err
+					}
+
+					return runCreateAccount(ctx, adapter, acct)
+				}
+			},
+		},
+	}
+}
+
+func runGetCurrentBalance(ctx context.Context, adapter *bank.BankAdapter, acct string) error {
+	balance, err := adapter.GetCurrentBalance(ctx, acct)
+
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Balance on %v is %v\n", balance.Date.Format("2006-01-02"), balance.Amount)
+
+	return nil
+}
+
+func runFetchExchangeRates(ctx context.Context, adapter *bank.BankAdapter, fromCur, toCur string) error {
+	rates, errs := adapter.FetchExchangeRates(ctx, fromCur, toCur)
+
+	return printStream(rates, errs, func(rate *dbank.ExchangeRate) {
+		log.Printf(
+			"Rate at %v from %v to %v is %v \n",
+			rate.Timestamp,
+			rate.FromCurrency,
+			rate.ToCurrency,
+			rate.Rate,
+		)
+	})
+}
+
+func runSummarizeTransactions(ctx context.Context, adapter *bank.BankAdapter, acct string, tx []*dbank.Transaction) error {
+	summary, err := adapter.SummarizeTransactions(ctx, acct, tx)
+
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Summary for %v on %v : in %v, out %v, total %v\n", summary.AccountNumber,
+		summary.TransactionDate.Format("2006-01-02"), summary.SumAmountIn, summary.SumAmountOut, summary.SumTotal)
+
+	return nil
+}
+
+func runTransferMultiple(ctx context.Context, adapter *bank.BankAdapter, trf []dbank.TransferTransaction) error {
+	results, errs := adapter.TransferMultiple(ctx, trf)
+
+	return printStream(results, errs, func(res *dbank.TransferResult) {
+		log.Printf("Transfer status %v on %v\n", res.Status, res.Timestamp)
+	})
+}
+
+func runCreateAccount(ctx context.Context, adapter *bank.BankAdapter, acct dbank.CreateAccount) error {
+	account, err := adapter.CreateAccount(ctx, acct)
+
+	if err != nil {
+		return err
+	}
+
+	log.Println("Account created :", account.AccountUUID)
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	dbank "github.com/fbriansyah/my-grpc-go-client/internal/application/domain/bank"
+	dresl "github.com/fbriansyah/my-grpc-go-client/internal/application/domain/resiliency"
+)
+
+// stringsFlag collects a comma separated list, and can be repeated.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*f = append(*f, v)
+		}
+	}
+
+	return nil
+}
+
+var statusCodeNames = map[string]uint32{
+	"OK":                 dresl.OK,
+	"CANCELLED":          dresl.CANCELLED,
+	"UNKNOWN":            dresl.UNKNOWN,
+	"INVALID_ARGUMENT":   dresl.INVALID_ARGUMENT,
+	"DEADLINE_EXCEEDED":  dresl.DEADLINE_EXCEEDED,
+	"NOT_FOUND":          dresl.NOT_FOUND,
+	"ALREADY_EXISTS":     dresl.ALREADY_EXISTS,
+	"PERMISSION_DENIED":  dresl.PERMISSION_DENIED,
+	"RESOURCE_EXHAUSTED": dresl.RESOURCE_EXHAUSTED,
+}
+
+// statusCodesFlag parses a comma separated list of status codes, given
+// either as numbers ("0,2") or names ("OK,UNKNOWN").
+type statusCodesFlag []uint32
+
+func (f *statusCodesFlag) String() string {
+	codes := make([]string, len(*f))
+	for i, c := range *f {
+		codes[i] = strconv.FormatUint(uint64(c), 10)
+	}
+
+	return strings.Join(codes, ",")
+}
+
+func (f *statusCodesFlag) Set(value string) error {
+	var codes []uint32
+
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+
+		if c, ok := statusCodeNames[strings.ToUpper(v)]; ok {
+			codes = append(codes, c)
+			continue
+		}
+
+		c, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid status code %q", v)
+		}
+
+		codes = append(codes, uint32(c))
+	}
+
+	*f = codes
+
+	return nil
+}
+
+// transactionsFlag parses repeated "TYPE:AMOUNT[:NOTES]" values,
+// e.g. -tx IN:100 -tx OUT:25.5:coffee.
+type transactionsFlag []*dbank.Transaction
+
+func (f *transactionsFlag) String() string {
+	return fmt.Sprint(len(*f), " transaction(s)")
+}
+
+func (f *transactionsFlag) Set(value string) error {
+	parts := strings.SplitN(value, ":", 3)
+	if len(parts) < 2 {
+		return fmt.Errorf("invalid transaction %q, want TYPE:AMOUNT[:NOTES]", value)
+	}
+
+	ttype := strings.ToUpper(parts[0])
+	if ttype != dbank.TransactionTypeIn && ttype != dbank.TransactionTypeOut {
+		return fmt.Errorf("invalid transaction type %q, want %v or %v",
+			parts[0], dbank.TransactionTypeIn, dbank.TransactionTypeOut)
+	}
+
+	amount, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return fmt.Errorf("invalid transaction amount %q", parts[1])
+	}
+
+	tx := &dbank.Transaction{
+		Amount:          amount,
+		TransactionType: ttype,
+	}
+
+	if len(parts) == 3 {
+		tx.Notes = parts[2]
+	}
+
+	*f = append(*f, tx)
+
+	return nil
+}
+
+// transfersFlag parses repeated "FROM:TO:CURRENCY:AMOUNT" values.
+type transfersFlag []dbank.TransferTransaction
+
+func (f *transfersFlag) String() string {
+	return fmt.Sprint(len(*f), " transfer(s)")
+}
+
+func (f *transfersFlag) Set(value string) error {
+	parts := strings.Split(value, ":")
+	if len(parts) != 4 {
+		return fmt.Errorf("invalid transfer %q, want FROM:TO:CURRENCY:AMOUNT", value)
+	}
+
+	amount, err := strconv.ParseFloat(parts[3], 64)
+	if err != nil {
+		return fmt.Errorf("invalid transfer amount %q", parts[3])
+	}
+
+	*f = append(*f, dbank.TransferTransaction{
+		FromAccountNumber: parts[0],
+		ToAccountNumber:   parts[1],
+		Currency:          parts[2],
+		Amount:            amount,
+	})
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+
+	"github.com/fbriansyah/my-grpc-go-client/internal/adapter/hello"
+	hello_proto "github.com/fbriansyah/my-grpc-proto/protogen/go/hello"
+	"google.golang.org/grpc"
+)
+
+func helloCommands() []command {
+	return []command{
+		{
+			service: "hello", name: "say", summary: "SayHello, unary",
+			setup: func(fs *flag.FlagSet) runFunc {
+				name := fs.String("name", "Febrian", "name to greet")
+
+				return func(ctx context.Context, conn *grpc.ClientConn) error {
+					adapter, err := hello.NewHelloAdapter(conn)
+					if err != nil {
+						return err
+					}
+
+					return runSayHello(ctx, adapter, *name)
+				}
+			},
+		},
+		{
+			service: "hello", name: "many", summary: "SayManyHello, server streaming",
+			setup: func(fs *flag.FlagSet) runFunc {
+				name := fs.String("name", "Rian", "name to greet")
+
+				return func(ctx context.Context, conn *grpc.ClientConn) error {
+					adapter, err := hello.NewHelloAdapter(conn)
+					if err != nil {
+						return err
+					}
+
+					return runSayManyHellos(ctx, adapter, *name)
+				}
+			},
+		},
+		{
+			service: "hello", name: "everyone", summary: "SayHelloToEveryone, client streaming",
+			setup: func(fs *flag.FlagSet) runFunc {
+				var names stringsFlag
+				fs.Var(&names, "names", "comma separated names to greet, can be repeated")
+
+				return func(ctx context.Context, conn *grpc.ClientConn) error {
+					if len(names) == 0 {
+						return errors.New("at least one name is required, use -names")
+					}
+
+					adapter, err := hello.NewHelloAdapter(conn)
+					if err != nil {
+						return err
+					}
+
+					return runSayHelloToEveryone(ctx, adapter, names)
+				}
+			},
+		},
+		{
+			service: "hello", name: "continuous", summary: "SayHelloContinuous, bidirectional streaming",
+			setup: func(fs *flag.FlagSet) runFunc {
+				var names stringsFlag
+				fs.Var(&names, "names", "comma separated names to greet, can be repeated")
+
+				return func(ctx context.Context, conn *grpc.ClientConn) error {
+					if len(names) == 0 {
+						return errors.New("at least one name is required, use -names")
+					}
+
+					adapter, err := hello.NewHelloAdapter(conn)
+					if err != nil {
+						return err
+					}
+
+					return runSayHelloContinuous(ctx, adapter, names)
+				}
+			},
+		},
+	}
+}
+
+func runSayHello(ctx context.Context, adapter *hello.HelloAdapter, name string) error {
+	greet, err := adapter.SayHello(ctx, name)
+
+	if err != nil {
+		return err
+	}
+
+	log.Println(greet.Greet)
+
+	return nil
+}
+
+func runSayManyHellos(ctx context.Context, adapter *hello.HelloAdapter, name string) error {
+	greets, errs := adapter.SayManyHello(ctx, name)
+
+	return printStream(greets, errs, func(greet *hello_proto.HelloResponse) {
+		log.Println(greet.Greet)
+	})
+}
+
+func runSayHelloToEveryone(ctx context.Context, adapter *hello.HelloAdapter, names []string) error {
+	greet, err := adapter.SayHelloToEveryone(ctx, names)
+
+	if err != nil {
+		return err
+	}
+
+	log.Println(greet.Greet)
+
+	return nil
+}
+
+func runSayHelloContinuous(ctx context.Context, adapter *hello.HelloAdapter, names []string) error {
+	greets, errs := adapter.SayHelloContinuous(ctx, names)
+
+	return printStream(greets, errs, func(greet *hello_proto.HelloResponse) {
+		log.Println(greet.Greet)
+	})
+}
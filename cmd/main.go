@@ -2,251 +2,476 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
 	"time"
 
-	"github.com/fbriansyah/my-grpc-go-client/internal/adapter/bank"
-	"github.com/fbriansyah/my-grpc-go-client/internal/adapter/hello"
-	"github.com/fbriansyah/my-grpc-go-client/internal/adapter/resiliency"
-	dresl "github.com/fbriansyah/my-grpc-go-client/internal/application/domain/resiliency"
+	"github.com/fbriansyah/my-grpc-go-client/internal/auth"
+	"github.com/fbriansyah/my-grpc-go-client/internal/callmeta"
+	"github.com/fbriansyah/my-grpc-go-client/internal/config"
 	"github.com/fbriansyah/my-grpc-go-client/internal/interceptor"
-	resl_proto "github.com/fbriansyah/my-grpc-proto/protogen/go/resiliency"
-
-	// grpc_retry "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/retry"
-	"github.com/sony/gobreaker"
+	"github.com/fbriansyah/my-grpc-go-client/internal/interceptor/redact"
+	"github.com/fbriansyah/my-grpc-go-client/internal/tlscreds"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 )
 
-var cbreaker *gobreaker.CircuitBreaker
+// version is the build version of the client, set with
+// -ldflags "-X main.version=...".
+var version = "dev"
 
-func init() {
-	mybreaker := gobreaker.Settings{
-		Name: "course-circuit-breaker",
-		ReadyToTrip: func(counts gobreaker.Counts) bool {
-			failureRatio := float64(counts.TotalFailures) / float64(counts.Requests)
+// newPolicy converts validated policy settings, taking its credentials out
+// of creds.
+func newPolicy(cfg config.PolicyConfig, creds map[string]*auth.Credentials) interceptor.Policy {
+	retryCodes, _ := cfg.Retry.StatusCodes()
+	failureCodes, _ := cfg.CircuitBreaker.FailureStatusCodes()
 
-			log.Printf("Circuit breaker failure is %v, requests is %v, means failure ratio : %v\n",
-				counts.TotalFailures, counts.Requests, failureRatio)
+	md := metadata.MD{}
+	for k, v := range cfg.Metadata {
+		md.Append(k, v)
+	}
 
-			return counts.Requests >= 3 && failureRatio >= 0.6
+	policy := interceptor.Policy{
+		Timeout: time.Duration(cfg.UnaryTimeout),
+		StreamTimeout: interceptor.StreamTimeout{
+			Lifetime: time.Duration(cfg.StreamTimeout),
+			Idle:     time.Duration(cfg.StreamIdleTimeout),
+		},
+		Retry: interceptor.RetryPolicy{
+			MaxAttempts:    cfg.Retry.MaxAttempts,
+			Codes:          retryCodes,
+			InitialBackoff: time.Duration(cfg.Retry.InitialBackoff),
+			MaxBackoff:     time.Duration(cfg.Retry.MaxBackoff),
+			Multiplier:     cfg.Retry.Multiplier,
+			Jitter:         cfg.Retry.Jitter,
 		},
-		Timeout:     4 * time.Second,
-		MaxRequests: 3,
-		OnStateChange: func(name string, from, to gobreaker.State) {
-			log.Printf("Circuit breaker %v changed state, from %v to %v\n\n", name, from, to)
+		Breaker: interceptor.BreakerPolicy{
+			MinRequests:  cfg.CircuitBreaker.MinRequests,
+			FailureRatio: cfg.CircuitBreaker.FailureRatio,
+			Timeout:      time.Duration(cfg.CircuitBreaker.Timeout),
+			MaxRequests:  cfg.CircuitBreaker.MaxRequests,
+			Interval:     time.Duration(cfg.CircuitBreaker.Interval),
+			FailureCodes: failureCodes,
 		},
+		Metadata: md,
 	}
 
-	cbreaker = gobreaker.NewCircuitBreaker(mybreaker)
+	// a nil *auth.Credentials must not become a non-nil interface
+	if c, ok := creds[cfg.Credentials]; ok {
+		policy.Credentials = c
+	}
+
+	return policy
 }
 
-func main() {
-	var opts []grpc.DialOption
-
-	// opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	// opts = append(opts,
-	// 	grpc.WithUnaryInterceptor(
-	// 		grpc_retry.UnaryClientInterceptor(
-	// 			grpc_retry.WithCodes(codes.Unknown, codes.Internal),
-	// 			grpc_retry.WithMax(4),
-	// 			grpc_retry.WithBackoff(grpc_retry.BackoffExponential(2*time.Second)),
-	// 		),
-	// 	),
-	// )
-
-	// opts = append(opts,
-	// 	grpc.WithStreamInterceptor(
-	// 		grpc_retry.StreamClientInterceptor(
-	// 			grpc_retry.WithCodes(codes.Unknown, codes.Internal),
-	// 			grpc_retry.WithMax(4),
-	// 			grpc_retry.WithBackoff(grpc_retry.BackoffLinear(3*time.Second)),
-	// 		),
-	// 	),
-	// )
-	opts = append(opts,
-		grpc.WithChainUnaryInterceptor(
-			interceptor.LogUnaryClientInterceptor(),
-			interceptor.BasicUnaryClientInterceptor(),
-			interceptor.TimeoutUnaryClientInterceptor(time.Second*5),
-		),
-	)
+// newPolicies converts the default settings and every configured policy
+// once.
+func newPolicies(cfg *config.Config) *interceptor.Policies {
+	creds := newCredentials(cfg.Credentials, cfg.TLS.Insecure)
 
-	opts = append(opts,
-		grpc.WithChainStreamInterceptor(
-			interceptor.LogStreamClientInterceptor(),
-			interceptor.BasicClientStreamInterceptor(),
-			interceptor.TimeoutStreamClientInterceptor(time.Second*20),
-		),
-	)
+	policies := map[string]interceptor.Policy{}
+	for pattern := range cfg.Policies {
+		policies[pattern] = newPolicy(cfg.Policy(pattern), creds)
+	}
 
-	conn, err := grpc.Dial("localhost:9090", opts...)
+	return interceptor.NewPolicies(newPolicy(cfg.DefaultPolicy(), creds), policies)
+}
 
-	if err != nil {
-		log.Fatalln(err)
+// newCredentials builds every configured credentials once, so that the
+// policies using them share their cached token. For oauth2, the token,
+// file or env is the client secret. Tokens are sent without
+// TLS only when the connection is insecure on purpose.
+func newCredentials(cfg map[string]config.CredentialsConfig, allowInsecure bool) map[string]*auth.Credentials {
+	creds := map[string]*auth.Credentials{}
+
+	for name, c := range cfg {
+		var src auth.TokenSource
+		switch {
+		case c.File != "" && c.RefreshInterval > 0:
+			src = auth.Refreshing(auth.FileToken(c.File, time.Duration(c.RefreshInterval)), 0)
+		case c.File != "":
+			src = auth.FileToken(c.File, 0)
+		case c.Env != "":
+			src = auth.EnvToken(c.Env)
+		default:
+			src = auth.StaticToken(c.Token)
+		}
+
+		var cred *auth.Credentials
+		switch c.Type {
+		case "api_key":
+			header := c.Header
+			if header == "" {
+				header = "x-api-key"
+			}
+			cred = auth.APIKey(header, src)
+		case "oauth2":
+			cred = auth.Bearer(auth.Refreshing(&auth.ClientCredentials{
+				TokenURL:     c.OAuth2.TokenURL,
+				ClientID:     c.OAuth2.ClientID,
+				ClientSecret: src,
+				Scopes:       c.OAuth2.Scopes,
+			}, c.OAuth2.EarlyRefresh()))
+		default:
+			cred = auth.Bearer(src)
+		}
+		cred.AllowInsecure = allowInsecure
+
+		creds[name] = cred
 	}
 
-	defer conn.Close()
-
-	// helloAdapter, err := hello.NewHelloAdapter(conn)
-	// bankAdapter, err := bank.NewBankAdapter(conn)
-	resiliencyAdapter, err := resiliency.NewResiliencyAdapter(conn)
+	return creds
+}
 
-	if err != nil {
-		log.Fatalln(err)
+// newMetadataProviders returns the providers of the metadata sent with
+// every call: the metadata of the policy of the method first, so it wins
+// over the static metadata, then the dynamic one.
+func newMetadataProviders(cfg config.MetadataConfig, policies *interceptor.Policies) []interceptor.MetadataProvider {
+	static := metadata.MD{}
+	for k, v := range cfg.Static {
+		static.Append(k, v)
 	}
 
-	// runSayHello(helloAdapter, "Febrian")
-	// runSayManyHellos(helloAdapter, "Rian")
+	providers := []interceptor.MetadataProvider{
+		interceptor.MethodMetadata(policies.Metadata),
+		interceptor.StaticMetadata(static),
+	}
 
-	// runSayHelloContinuous(helloAdapter, []string{"Feb", "Rian", "Nuur", "Rasyiid"})
+	for _, name := range cfg.Dynamic {
+		switch name {
+		case "request-uuid":
+			providers = append(providers, interceptor.RequestUUID())
+		case "client-time":
+			providers = append(providers, interceptor.ClientTime())
+		case "client-os":
+			providers = append(providers, interceptor.ClientOS())
+		case "client-version":
+			providers = append(providers, interceptor.ClientVersion(version))
+		}
+	}
 
-	// runGetCurrentBalance(bankAdapter, "7835697001xxxx")
-	runUnaryResiliencyWithTimeout(resiliencyAdapter, 3, 4, []uint32{dresl.OK}, time.Second*2)
-	// for i := 0; i < 300; i++ {
-	// 	runUnaryResiliencyWithCircuitBreaker(resiliencyAdapter, 0, 0, []uint32{dresl.UNKNOWN, dresl.OK})
-	// 	time.Sleep(time.Second)
-	// }
+	return providers
 }
 
-func runSayHello(adapter *hello.HelloAdapter, name string) {
-	greet, err := adapter.SayHello(context.Background(), name)
-
-	if err != nil {
-		log.Fatalln(err)
+// newBreakers builds the circuit breakers shared by every call.
+func newBreakers(cfg config.CircuitBreakerConfig, policies *interceptor.Policies) *interceptor.Breakers {
+	key := interceptor.BreakerPerMethod
+	if cfg.Scope == "target" {
+		key = interceptor.BreakerPerTarget
 	}
 
-	log.Println(greet.Greet)
+	return interceptor.NewBreakers(key, policies.Breaker)
 }
 
-func runSayManyHellos(adapter *hello.HelloAdapter, name string) {
-	adapter.SayManyHello(context.Background(), name)
+// runFunc runs a subcommand against an open connection.
+type runFunc func(ctx context.Context, conn *grpc.ClientConn) error
+
+// command is a single "<service> <name>" subcommand. setup registers the
+// subcommand flags on fs and returns the function that runs the call once
+// the flags are parsed and the connection is open.
+type command struct {
+	service string
+	name    string
+	summary string
+	setup   func(fs *flag.FlagSet) runFunc
 }
 
-func runSayHelloToEveryone(adapter *hello.HelloAdapter, names []string) {
-	adapter.SayHelloToEveryone(context.Background(), names)
+var commands []command
+
+func init() {
+	commands = append(commands, helloCommands()...)
+	commands = append(commands, bankCommands()...)
+	commands = append(commands, resiliencyCommands()...)
 }
 
-func runSayHelloContinuous(adapter *hello.HelloAdapter, names []string) {
-	adapter.SayHelloContinuous(context.Background(), names)
+func findCommand(service, name string) (command, bool) {
+	for _, c := range commands {
+		if c.service == service && c.name == name {
+			return c, true
+		}
+	}
+
+	return command{}, false
 }
 
-func runGetCurrentBalance(adapter *bank.BankAdapter, acct string) {
-	balance, _ := adapter.GetCurrentBalance(context.Background(), acct)
+func usage() {
+	out := flag.CommandLine.Output()
 
-	log.Println(balance)
+	fmt.Fprintf(out, "Usage: %v [flags] <service> <command> [command flags]\n\n", os.Args[0])
+	fmt.Fprintln(out, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-10v %-14v %v\n", c.service, c.name, c.summary)
+	}
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+	fmt.Fprintf(out, "\nRun '%v <service> <command> -h' for command flags.\n", os.Args[0])
 }
 
-func runUnaryResiliencyWithTimeout(adapter *resiliency.ResiliencyAdapter, minDelay int32,
-	maxDelay int32, statusCodes []uint32, timeout time.Duration) {
+func main() {
+	configFlags := config.BindFlags(flag.CommandLine)
+	showMetadata := flag.Bool("show-metadata", false, "print the response header and trailer of the last call")
+	logJSON := flag.Bool("log-json", false, "write the call logs to stderr as JSON")
+	demoTransform := flag.Bool("demo-transform", false, "prefix hello and resiliency messages to show the transformers at work")
+	traceFile := flag.String("trace-file", "", "write the spans of the calls to this file as JSON, - for stdout")
+	metricsAddr := flag.String("metrics-addr", "", "serve the client metrics on http://<addr>/metrics while the command runs, e.g. localhost:9100")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := findCommand(flag.Arg(0), flag.Arg(1))
+	if !ok {
+		fmt.Fprintf(flag.CommandLine.Output(), "unknown command %q\n\n", flag.Arg(0)+" "+flag.Arg(1))
+		flag.Usage()
+		os.Exit(2)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+	fs := flag.NewFlagSet(cmd.service+" "+cmd.name, flag.ExitOnError)
+	run := cmd.setup(fs)
+	fs.Parse(flag.Args()[2:])
 
-	res, err := adapter.UnaryResiliency(ctx, minDelay, maxDelay, statusCodes)
+	cfg, err := config.Load(configFlags)
 	if err != nil {
-		log.Fatalln("Failed to call UnaryResiliency :", err)
+		log.Fatalln(err)
 	}
-	log.Println(res.DummyString)
-}
 
-func runServerStreamingResiliencyWithTimeout(adapter *resiliency.ResiliencyAdapter, minDelay int32,
-	maxDelay int32, statusCodes []uint32, timeout time.Duration) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+	redactor, err := redact.New(redact.BankRules.Merge(cfg.Logging.Redact))
+	if err != nil {
+		log.Fatalln(err)
+	}
 
-	adapter.ServerStreamingResiliency(ctx, minDelay, maxDelay, statusCodes)
-}
+	logOpts := interceptor.LogOptions{
+		Payloads: cfg.Logging.Payloads,
+		Redact:   redactor.Redact,
+	}
+	if *logJSON {
+		logOpts.Logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	}
 
-func runClientStreamingResiliencyWithTimeout(adapter *resiliency.ResiliencyAdapter, minDelay int32,
-	maxDelay int32, statusCodes []uint32, count int, timeout time.Duration) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+	transformers := interceptor.NewTransformers()
+	if *demoTransform {
+		interceptor.RegisterDemoTransformers(transformers)
+	}
 
-	adapter.ClientStreamingResiliency(ctx, minDelay, maxDelay, statusCodes, count)
-}
+	metrics, err := interceptor.NewMetrics(prometheus.DefaultRegisterer)
+	if err != nil {
+		log.Fatalln(err)
+	}
 
-func runBiDirectionalResiliencyWithTimeout(adapter *resiliency.ResiliencyAdapter,
-	minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32,
-	count int, timeout time.Duration) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+	if *metricsAddr != "" {
+		if err := serveMetrics(*metricsAddr); err != nil {
+			log.Fatalln(err)
+		}
+	}
 
-	adapter.BiDirectionalResiliency(ctx, minDelaySecond, maxDelaySecond, statusCodes, count)
-}
+	traceOpts, shutdownTracing, err := newTraceOptions(*traceFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
 
-func runUnaryResiliency(adapter *resiliency.ResiliencyAdapter, minDelaySecond int32,
-	maxDelaySecond int32, statusCodes []uint32) {
-	res, err := adapter.UnaryResiliency(context.Background(), minDelaySecond, maxDelaySecond, statusCodes)
+	conn := dial(cfg, logOpts, transformers, metrics, traceOpts)
+	defer conn.Close()
 
-	if err != nil {
-		log.Fatalln("Failed to call UnaryResiliency :", err)
+	ctx := context.Background()
+	if timeout := cfg.ServiceTimeout(cmd.service); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	log.Println(res.DummyString)
-}
+	var capture *callmeta.Metadata
+	if *showMetadata {
+		ctx, capture = callmeta.WithCapture(ctx)
+	}
 
-func runServerStreamingResiliency(adapter *resiliency.ResiliencyAdapter,
-	minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32) {
-	adapter.ServerStreamingResiliency(context.Background(), minDelaySecond, maxDelaySecond, statusCodes)
-}
+	err = run(ctx, conn)
+	shutdownTracing()
 
-func runClientStreamingResiliency(adapter *resiliency.ResiliencyAdapter,
-	minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32,
-	count int) {
-	adapter.ClientStreamingResiliency(context.Background(), minDelaySecond,
-		maxDelaySecond, statusCodes, count)
+	if capture != nil {
+		printMetadata("Request metadata", capture.Request)
+		printMetadata("Response header", capture.Header)
+		printMetadata("Response trailer", capture.Trailer)
+	}
+
+	if err != nil {
+		log.Fatalln(err)
+	}
 }
 
-func runBiDirectionalResiliency(adapter *resiliency.ResiliencyAdapter,
-	minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32,
-	count int) {
-	adapter.BiDirectionalResiliency(context.Background(), minDelaySecond,
-		maxDelaySecond, statusCodes, count)
+// printStream logs every message received from an adapter stream and
+// returns the error the stream ended with.
+func printStream[T any](msgs <-chan T, errs <-chan error, print func(T)) error {
+	for msg := range msgs {
+		print(msg)
+	}
+
+	return <-errs
 }
 
-func runUnaryResiliencyWithCircuitBreaker(adapter *resiliency.ResiliencyAdapter,
-	minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32) {
-	cbreakerRes, cbreakerErr := cbreaker.Execute(
-		func() (interface{}, error) {
-			return adapter.UnaryResiliency(context.Background(), minDelaySecond, maxDelaySecond, statusCodes)
-		},
-	)
+func printMetadata(title string, md metadata.MD) {
+	if md.Len() == 0 {
+		log.Println(title, "not found")
+		return
+	}
 
-	if cbreakerErr != nil {
-		log.Println("Failed to call UnaryResiliency :", cbreakerErr)
-	} else {
-		log.Println(cbreakerRes.(*resl_proto.ResiliencyResponse).DummyString)
+	log.Println(title, ":")
+	for k, v := range md {
+		log.Printf("  %v : %v\n", k, v)
 	}
 }
 
-func runUnaryResiliencyWithMetadata(adapter *resiliency.ResiliencyAdapter, minDelaySecond int32,
-	maxDelaySecond int32, statusCodes []uint32) {
-	res, err := adapter.UnaryResiliencyWithMetadata(context.Background(),
-		minDelaySecond, maxDelaySecond, statusCodes)
+// serveMetrics serves the metrics of the default registry on addr, in the
+// background. It listens before returning, so that an address in use fails
+// the command before any call is made.
+func serveMetrics(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("metrics: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	go func() {
+		if err := http.Serve(lis, mux); err != nil {
+			log.Println("metrics:", err)
+		}
+	}()
+
+	return nil
+}
+
+// newTraceOptions returns the options of the tracing interceptors, exporting
+// the spans to path, and the function flushing them. Without a path the
+// spans go to the global provider, a no-op unless set.
+func newTraceOptions(path string) (interceptor.TraceOptions, func(), error) {
+	if path == "" {
+		return interceptor.TraceOptions{}, func() {}, nil
+	}
+
+	out := os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return interceptor.TraceOptions{}, nil, err
+		}
+		out = f
+	}
 
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(out), stdouttrace.WithPrettyPrint())
 	if err != nil {
-		log.Fatalln("Failed to call UnaryResiliencyWithMetadata :", err)
+		return interceptor.TraceOptions{}, nil, err
 	}
 
-	log.Println(res.DummyString)
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName("my-grpc-client"),
+			semconv.ServiceVersion(version),
+		)),
+	)
+
+	shutdown := func() {
+		if err := provider.Shutdown(context.Background()); err != nil {
+			log.Println("Cannot export spans :", err)
+		}
+
+		if out != os.Stdout {
+			out.Close()
+		}
+	}
+
+	return interceptor.TraceOptions{Provider: provider}, shutdown, nil
 }
 
-func runServerStreamingResiliencyWithMetadata(adapter *resiliency.ResiliencyAdapter,
-	minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32) {
-	adapter.ServerStreamingResiliencyWithMetadata(context.Background(), minDelaySecond,
-		maxDelaySecond, statusCodes)
+// newTransportCredentials returns the credentials securing the connection,
+// TLS unless insecure is set.
+func newTransportCredentials(cfg config.TLSConfig) (credentials.TransportCredentials, error) {
+	if cfg.Insecure {
+		return insecure.NewCredentials(), nil
+	}
+
+	return tlscreds.New(tlscreds.Options{
+		CAFile:         cfg.CAFile,
+		CertFile:       cfg.CertFile,
+		KeyFile:        cfg.KeyFile,
+		ServerName:     cfg.ServerName,
+		ReloadInterval: time.Duration(cfg.ReloadInterval),
+	})
 }
 
-func runClientStreamingResiliencyWithMetadata(adapter *resiliency.ResiliencyAdapter,
-	minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32,
-	count int) {
-	adapter.ClientStreamingResiliencyWithMetadata(context.Background(), minDelaySecond,
-		maxDelaySecond, statusCodes, count)
+// interceptors returns the interceptor chains of every call. The timeouts
+// wrap the retries, so that they bound the whole call, attempts and backoffs
+// included, and the retries stop once the time is up.
+func interceptors(cfg *config.Config, policies *interceptor.Policies, logOpts interceptor.LogOptions,
+	transformers *interceptor.Transformers, metrics *interceptor.Metrics,
+	traceOpts interceptor.TraceOptions) []grpc.DialOption {
+	breakers := newBreakers(cfg.CircuitBreaker, policies)
+	breakers.OnStateChange(interceptor.LogBreakerStateChange(logOpts.Logger))
+	breakers.OnStateChange(metrics.BreakerStateChange)
+	metadataProviders := newMetadataProviders(cfg.Metadata, policies)
+
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(
+			interceptor.LogUnaryClientInterceptor(logOpts),
+			interceptor.MetricsUnaryClientInterceptor(metrics),
+			interceptor.TraceUnaryClientInterceptor(traceOpts),
+			interceptor.TransformUnaryClientInterceptor(transformers),
+			interceptor.MetadataUnaryClientInterceptor(metadataProviders...),
+			interceptor.BreakerUnaryClientInterceptor(breakers),
+			interceptor.TimeoutUnaryClientInterceptor(policies.Timeout),
+			interceptor.RetryUnaryClientInterceptor(policies.Retry, logOpts.Logger),
+		),
+		grpc.WithChainStreamInterceptor(
+			interceptor.LogStreamClientInterceptor(logOpts),
+			interceptor.MetricsStreamClientInterceptor(metrics),
+			interceptor.TraceStreamClientInterceptor(traceOpts),
+			interceptor.TransformStreamClientInterceptor(transformers),
+			interceptor.MetadataStreamClientInterceptor(metadataProviders...),
+			interceptor.BreakerStreamClientInterceptor(breakers),
+			interceptor.TimeoutStreamClientInterceptor(policies.StreamTimeout),
+			interceptor.RetryStreamClientInterceptor(policies.Retry, logOpts.Logger),
+		),
+	}
 }
 
-func runBiDirectionalResiliencyWithMetadata(adapter *resiliency.ResiliencyAdapter,
-	minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32,
-	count int) {
-	adapter.BiDirectionalResiliencyWithMetadata(context.Background(), minDelaySecond,
-		maxDelaySecond, statusCodes, count)
+func dial(cfg *config.Config, logOpts interceptor.LogOptions, transformers *interceptor.Transformers,
+	metrics *interceptor.Metrics, traceOpts interceptor.TraceOptions) *grpc.ClientConn {
+	creds, err := newTransportCredentials(cfg.TLS)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	policies := newPolicies(cfg)
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithPerRPCCredentials(auth.PerMethod(policies.Credentials)),
+	}
+
+	opts = append(opts, interceptors(cfg, policies, logOpts, transformers, metrics, traceOpts)...)
+
+	conn, err := grpc.Dial(cfg.Target, opts...)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	return conn
 }
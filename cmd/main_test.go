@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fbriansyah/my-grpc-go-client/internal/config"
+	"github.com/fbriansyah/my-grpc-go-client/internal/fakeserver"
+	"github.com/fbriansyah/my-grpc-go-client/internal/interceptor"
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/bank"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// startChain dials the fake server through the interceptors main builds
+// from cfg.
+func startChain(t *testing.T, cfg *config.Config) (*fakeserver.Server, *grpc.ClientConn) {
+	t.Helper()
+
+	metrics, err := interceptor.NewMetrics(prometheus.NewRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logOpts := interceptor.LogOptions{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	return fakeserver.Start(t,
+		interceptors(cfg, newPolicies(cfg), logOpts, interceptor.NewTransformers(), metrics, interceptor.TraceOptions{})...)
+}
+
+// unavailableAfter fails every call with codes.Unavailable after delay.
+func unavailableAfter(delay time.Duration) fakeserver.Behavior {
+	return fakeserver.Behavior{Delay: delay, Err: status.Error(codes.Unavailable, "backend down")}
+}
+
+func TestTimeoutBoundsRetriedUnaryCall(t *testing.T) {
+	cfg := config.Default()
+	cfg.Policies["/bank.BankService/GetCurrentBalance"] = config.PolicyConfig{
+		TimeoutConfig: config.TimeoutConfig{UnaryTimeout: config.Duration(300 * time.Millisecond)},
+		Retry:         config.RetryPolicyConfig{MaxAttempts: 3},
+	}
+
+	srv, conn := startChain(t, cfg)
+	srv.Bank.On("GetCurrentBalance", unavailableAfter(250*time.Millisecond))
+
+	start := time.Now()
+	_, err := bank.NewBankServiceClient(conn).GetCurrentBalance(context.Background(), &bank.CurrentBalanceRequest{})
+	elapsed := time.Since(start)
+
+	if got := status.Code(err); got != codes.Unavailable {
+		t.Errorf("code = %v, want the last error %v", got, codes.Unavailable)
+	}
+
+	if elapsed > 450*time.Millisecond {
+		t.Errorf("call took %v, want it bounded by its 300ms timeout", elapsed)
+	}
+
+	if got := len(srv.Bank.Calls("GetCurrentBalance")); got != 1 {
+		t.Errorf("server got %d calls, want no retry that cannot finish in time", got)
+	}
+}
+
+func TestTimeoutBoundsRetriedStream(t *testing.T) {
+	cfg := config.Default()
+	cfg.Policies["/bank.BankService/FetchExchangeRates"] = config.PolicyConfig{
+		TimeoutConfig: config.TimeoutConfig{StreamTimeout: config.Duration(300 * time.Millisecond)},
+		Retry:         config.RetryPolicyConfig{MaxAttempts: 3},
+	}
+
+	srv, conn := startChain(t, cfg)
+	srv.Bank.On("FetchExchangeRates", unavailableAfter(250*time.Millisecond))
+
+	start := time.Now()
+	stream, err := bank.NewBankServiceClient(conn).FetchExchangeRates(context.Background(), &bank.ExchangeRateRequest{})
+	if err != nil {
+		t.Fatalf("FetchExchangeRates: %v", err)
+	}
+
+	_, err = stream.Recv()
+	elapsed := time.Since(start)
+
+	if got := status.Code(err); got != codes.Unavailable {
+		t.Errorf("code = %v, want the last error %v", got, codes.Unavailable)
+	}
+
+	if elapsed > 450*time.Millisecond {
+		t.Errorf("stream took %v, want it bounded by its 300ms timeout", elapsed)
+	}
+
+	if got := len(srv.Bank.Calls("FetchExchangeRates")); got != 1 {
+		t.Errorf("server got %d streams, want no retry that cannot finish in time", got)
+	}
+}
+
+func TestExampleConfigBalanceBudget(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	configFlags := config.BindFlags(fs)
+	if err := fs.Parse([]string{"-config", filepath.Join("..", "config.example.yaml")}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.Load(configFlags)
+	if err != nil {
+		t.Fatalf("load config.example.yaml: %v", err)
+	}
+
+	srv, conn := startChain(t, cfg)
+	srv.Bank.On("GetCurrentBalance", unavailableAfter(250*time.Millisecond))
+
+	start := time.Now()
+	bank.NewBankServiceClient(conn).GetCurrentBalance(context.Background(), &bank.CurrentBalanceRequest{})
+
+	if elapsed := time.Since(start); elapsed > 450*time.Millisecond {
+		t.Errorf("GetCurrentBalance took %v, want the 300ms budget of the example kept", elapsed)
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/fbriansyah/my-grpc-go-client/internal/adapter/resiliency"
+	dresl "github.com/fbriansyah/my-grpc-go-client/internal/application/domain/resiliency"
+	resl_proto "github.com/fbriansyah/my-grpc-proto/protogen/go/resiliency"
+	"google.golang.org/grpc"
+)
+
+// resiliencyFlags are the flags shared by every resiliency subcommand.
+type resiliencyFlags struct {
+	minDelay    int
+	maxDelay    int
+	statusCodes statusCodesFlag
+	timeout     time.Duration
+	metadata    bool
+}
+
+func addResiliencyFlags(fs *flag.FlagSet) *resiliencyFlags {
+	f := &resiliencyFlags{statusCodes: statusCodesFlag{dresl.OK}}
+
+	fs.IntVar(&f.minDelay, "min-delay", 0, "minimum server delay in seconds")
+	fs.IntVar(&f.maxDelay, "max-delay", 0, "maximum server delay in seconds")
+	fs.Var(&f.statusCodes, "status-codes", "comma separated status codes the server picks from, by number or name")
+	fs.DurationVar(&f.timeout, "timeout", 0, "deadline for the whole call, 0 means none")
+	fs.BoolVar(&f.metadata, "metadata", false, "call the ResiliencyWithMetadataService variant")
+
+	return f
+}
+
+// context returns ctx bounded by the -timeout flag when it is set.
+func (f *resiliencyFlags) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if f.timeout > 0 {
+		return context.WithTimeout(ctx, f.timeout)
+	}
+
+	return context.WithCancel(ctx)
+}
+
+func resiliencyCommands() []command {
+	return []command{
+		{
+			service: "resiliency", name: "unary", summary: "UnaryResiliency",
+			setup: func(fs *flag.FlagSet) runFunc {
+				f := addResiliencyFlags(fs)
+				keepGoing := fs.Bool("keep-going", false,
+					"log failed calls and keep repeating, e.g. to watch the circuit breaker trip")
+				repeat := fs.Int("repeat", 1, "number of calls to make")
+				interval := fs.Duration("interval", time.Second, "pause between repeated calls")
+
+				return func(ctx context.Context, conn *grpc.ClientConn) error {
+					adapter, err := resiliency.NewResiliencyAdapter(conn)
+					if err != nil {
+						return err
+					}
+
+					for i := 0; i < *repeat; i++ {
+						if i > 0 {
+							time.Sleep(*interval)
+						}
+
+						callCtx, cancel := f.context(ctx)
+
+						if f.metadata {
+							err = runUnaryResiliencyWithMetadata(callCtx, adapter,
+								int32(f.minDelay), int32(f.maxDelay), f.statusCodes)
+						} else {
+							err = runUnaryResiliency(callCtx, adapter,
+								int32(f.minDelay), int32(f.maxDelay), f.statusCodes)
+						}
+
+						cancel()
+
+						if err != nil && *keepGoing {
+							log.Println("Failed to call UnaryResiliency :", err)
+						} else if err != nil {
+							return err
+						}
+					}
+
+					return nil
+				}
+			},
+		},
+		{
+			service: "resiliency", name: "server-stream", summary: "ServerStreamingResiliency",
+			setup: func(fs *flag.FlagSet) runFunc {
+				f := addResiliencyFlags(fs)
+
+				return func(ctx context.Context, conn *grpc.ClientConn) error {
+					adapter, err := resiliency.NewResiliencyAdapter(conn)
+					if err != nil {
+						return err
+					}
+
+					ctx, cancel := f.context(ctx)
+					defer cancel()
+
+					if f.metadata {
+						return runServerStreamingResiliencyWithMetadata(ctx, adapter,
+							int32(f.minDelay), int32(f.maxDelay), f.statusCodes)
+					}
+
+					return runServerStreamingResiliency(ctx, adapter,
+						int32(f.minDelay), int32(f.maxDelay), f.statusCodes)
+				}
+			},
+		},
+		{
+			service: "resiliency", name: "client-stream", summary: "ClientStreamingResiliency",
+			setup: func(fs *flag.FlagSet) runFunc {
+				f := addResiliencyFlags(fs)
+				count := fs.Int("count", 3, "number of requests to send")
+
+				return func(ctx context.Context, conn *grpc.ClientConn) error {
+					adapter, err := resiliency.NewResiliencyAdapter(conn)
+					if err != nil {
+						return err
+					}
+
+					ctx, cancel := f.context(ctx)
+					defer cancel()
+
+					if f.metadata {
+						return runClientStreamingResiliencyWithMetadata(ctx, adapter,
+							int32(f.minDelay), int32(f.maxDelay), f.statusCodes, *count)
+					}
+
+					return runClientStreamingResiliency(ctx, adapter,
+						int32(f.minDelay), int32(f.maxDelay), f.statusCodes, *count)
+				}
+			},
+		},
+		{
+			service: "resiliency", name: "bidi", summary: "BiDirectionalResiliency",
+			setup: func(fs *flag.FlagSet) runFunc {
+				f := addResiliencyFlags(fs)
+				count := fs.Int("count", 3, "number of requests to send")
+
+				return func(ctx context.Context, conn *grpc.ClientConn) error {
+					adapter, err := resiliency.NewResiliencyAdapter(conn)
+					if err != nil {
+						return err
+					}
+
+					ctx, cancel := f.context(ctx)
+					defer cancel()
+
+					if f.metadata {
+						return runBiDirectionalResiliencyWithMetadata(ctx, adapter,
+							int32(f.minDelay), int32(f.maxDelay), f.statusCodes, *count)
+					}
+
+					return runBiDirectionalResiliency(ctx, adapter,
+						int32(f.minDelay), int32(f.maxDelay), f.statusCodes, *count)
+				}
+			},
+		},
+	}
+}
+
+func runUnaryResiliency(ctx context.Context, adapter *resiliency.ResiliencyAdapter, minDelaySecond int32,
+	maxDelaySecond int32, statusCodes []uint32) error {
+	res, err := adapter.UnaryResiliency(ctx, minDelaySecond, maxDelaySecond, statusCodes)
+
+	if err != nil {
+		return err
+	}
+
+	log.Println(res.DummyString)
+
+	return nil
+}
+
+func runServerStreamingResiliency(ctx context.Context, adapter *resiliency.ResiliencyAdapter,
+	minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32) error {
+	reslChan, errChan := adapter.ServerStreamingResiliency(ctx, minDelaySecond, maxDelaySecond, statusCodes)
+
+	return printStream(reslChan, errChan, func(res *resl_proto.ResiliencyResponse) {
+		log.Println(res.DummyString)
+	})
+}
+
+func runClientStreamingResiliency(ctx context.Context, adapter *resiliency.ResiliencyAdapter,
+	minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32,
+	count int) error {
+	res, err := adapter.ClientStreamingResiliency(ctx, minDelaySecond,
+		maxDelaySecond, statusCodes, count)
+
+	if err != nil {
+		return err
+	}
+
+	log.Println(res.DummyString)
+
+	return nil
+}
+
+func runBiDirectionalResiliency(ctx context.Context, adapter *resiliency.ResiliencyAdapter,
+	minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32,
+	count int) error {
+	reslChan, errChan := adapter.BiDirectionalResiliency(ctx, minDelaySecond,
+		maxDelaySecond, statusCodes, count)
+
+	return printStream(reslChan, errChan, func(res *resl_proto.ResiliencyResponse) {
+		log.Println(res.DummyString)
+	})
+}
+
+func runUnaryResiliencyWithMetadata(ctx context.Context, adapter *resiliency.ResiliencyAdapter, minDelaySecond int32,
+	maxDelaySecond int32, statusCodes []uint32) error {
+	res, callMd, err := adapter.UnaryResiliencyWithMetadata(ctx,
+		minDelaySecond, maxDelaySecond, statusCodes)
+	printCallMetadata(callMd)
+
+	if err != nil {
+		return err
+	}
+
+	log.Println(res.DummyString)
+
+	return nil
+}
+
+func runServerStreamingResiliencyWithMetadata(ctx context.Context, adapter *resiliency.ResiliencyAdapter,
+	minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32) error {
+	reslChan, errChan, callMd := adapter.ServerStreamingResiliencyWithMetadata(ctx, minDelaySecond,
+		maxDelaySecond, statusCodes)
+
+	err := printStream(reslChan, errChan, func(res *resl_proto.ResiliencyResponse) {
+		log.Println(res.DummyString)
+	})
+	printCallMetadata(callMd)
+
+	return err
+}
+
+func runClientStreamingResiliencyWithMetadata(ctx context.Context, adapter *resiliency.ResiliencyAdapter,
+	minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32,
+	count int) error {
+	res, callMd, err := adapter.ClientStreamingResiliencyWithMetadata(ctx, minDelaySecond,
+		maxDelaySecond, statusCodes, count)
+	printCallMetadata(callMd)
+
+	if err != nil {
+		return err
+	}
+
+	log.Println(res.DummyString)
+
+	return nil
+}
+
+func runBiDirectionalResiliencyWithMetadata(ctx context.Context, adapter *resiliency.ResiliencyAdapter,
+	minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32,
+	count int) error {
+	reslChan, errChan, callMd := adapter.BiDirectionalResiliencyWithMetadata(ctx, minDelaySecond,
+		maxDelaySecond, statusCodes, count)
+
+	err := printStream(reslChan, errChan, func(res *resl_proto.ResiliencyResponse) {
+		log.Println(res.DummyString)
+	})
+	printCallMetadata(callMd)
+
+	return err
+}
+
+func printCallMetadata(callMd *resiliency.CallMetadata) {
+	printMetadata("Request metadata", callMd.Request)
+	for i, msg := range callMd.Messages {
+		log.Printf("Request %v : uuid %v sent at %v\n", i+1, msg.RequestUUID, msg.ClientTime.Format("15:04:05.000"))
+	}
+	printMetadata("Response header", callMd.Header)
+	printMetadata("Response trailer", callMd.Trailer)
+}
@@ -2,16 +2,17 @@ package bank
 
 import (
 	"context"
+	"errors"
 	"io"
 	"log"
 
+	"github.com/fbriansyah/my-grpc-go-client/internal/adapter/rpcerr"
+	"github.com/fbriansyah/my-grpc-go-client/internal/adapter/stream"
 	dbank "github.com/fbriansyah/my-grpc-go-client/internal/application/domain/bank"
+	"github.com/fbriansyah/my-grpc-go-client/internal/callmeta"
 	"github.com/fbriansyah/my-grpc-go-client/internal/port"
 	"github.com/fbriansyah/my-grpc-proto/protogen/go/bank"
-	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type BankAdapter struct {
@@ -21,28 +22,34 @@ type BankAdapter struct {
 func NewBankAdapter(conn *grpc.ClientConn) (*BankAdapter, error) {
 	client := bank.NewBankServiceClient(conn)
 
+	return NewBankAdapterWithClient(client)
+}
+
+// NewBankAdapterWithClient builds the adapter on any client, e.g. a
+// portmock.BankClient in tests.
+func NewBankAdapterWithClient(client port.BankClientPort) (*BankAdapter, error) {
 	return &BankAdapter{
 		bankClient: client,
 	}, nil
 }
 
-func (a *BankAdapter) GetCurrentBalance(ctx context.Context, acct string) (*bank.CurrentBalanceResponse, error) {
+func (a *BankAdapter) GetCurrentBalance(ctx context.Context, acct string) (*dbank.Balance, error) {
 	req := &bank.CurrentBalanceRequest{
 		AccountNumber: acct,
 	}
 
-	resp, err := a.bankClient.GetCurrentBalance(ctx, req)
+	resp, err := a.bankClient.GetCurrentBalance(ctx, req, callmeta.FromContext(ctx).CallOptions()...)
 	if err != nil {
-		st, _ := status.FromError(err)
-		log.Fatalf("Code: %v | %v\n", st.Code().String(), st.Message())
-
-		return resp, err
+		return nil, rpcerr.New("BankAdapter.GetCurrentBalance", err)
 	}
 
-	return resp, nil
+	return toBalance(resp), nil
 }
 
-func (a *BankAdapter) FetchExchangeRates(ctx context.Context, fromCur, toCur string) {
+// FetchExchangeRates delivers every rate streamed by the server on the
+// first channel.
+func (a *BankAdapter) FetchExchangeRates(ctx context.Context, fromCur, toCur string) (
+	<-chan *dbank.ExchangeRate, <-chan error) {
 
 	req := &bank.ExchangeRateRequest{
 		FromCurrency: fromCur,
@@ -53,82 +60,63 @@ func (a *BankAdapter) FetchExchangeRates(ctx context.Context, fromCur, toCur str
 	exchangeStream, err := a.bankClient.FetchExchangeRates(ctx, req)
 
 	if err != nil {
-		st, _ := status.FromError(err)
-		log.Fatalf("[Fatal] error FetchExchangeRates: %v | %v\n", st.Code().String(), st.Message())
+		return stream.Fail[*dbank.ExchangeRate]("BankAdapter.FetchExchangeRates", err)
 	}
 
-	for {
-		rate, err := exchangeStream.Recv()
+	recv := callmeta.Recv(callmeta.FromContext(ctx), exchangeStream, exchangeStream.Recv)
 
-		if err == io.EOF {
-			break
-		}
+	return stream.Receive(ctx, "BankAdapter.FetchExchangeRates", func() (*dbank.ExchangeRate, error) {
+		rate, err := recv()
 
 		if err != nil {
-			st, _ := status.FromError(err)
-
-			if st.Code() == codes.InvalidArgument {
-				log.Fatalln("[Fatal] error FetchExchangeRates :", st.Message())
-			}
+			return nil, err
 		}
 
-		log.Printf(
-			"Rate at %v from %v to %v is %v \n",
-			rate.Timestamp,
-			rate.FromCurrency,
-			rate.ToCurrency,
-			rate.Rate,
-		)
-	}
-
+		return toExchangeRate(rate), nil
+	})
 }
 
-func (a *BankAdapter) SummarizeTransactions(ctx context.Context, acct string, tx []*dbank.Transaction) {
+func (a *BankAdapter) SummarizeTransactions(ctx context.Context, acct string,
+	tx []*dbank.Transaction) (*dbank.TransactionSummary, error) {
 	txStreamm, err := a.bankClient.SummarizeTransactions(ctx)
 
 	if err != nil {
-		st, _ := status.FromError(err)
-		log.Fatalf("[Fatal] error SummarizeTransactions: %v", st.Err())
+		return nil, rpcerr.New("BankAdapter.SummarizeTransactions", err)
 	}
 
 	for _, t := range tx {
-		ttype := bank.TransactionType_TRANSACTION_TYPE_UNSPECIFIED
-
-		if t.TransactionType == dbank.TransactionTypeIn {
-			ttype = bank.TransactionType_TRANSACTION_TYPE_IN
-		} else if t.TransactionType == dbank.TransactionTypeOut {
-			ttype = bank.TransactionType_TRANSACTION_TYPE_OUT
-		}
-
 		req := &bank.Transaction{
 			AccountNumber: acct,
-			Type:          ttype,
+			Type:          toProtoTransactionType(t.TransactionType),
 			Amount:        t.Amount,
 			Notes:         t.Notes,
 		}
-		txStreamm.Send(req)
+
+		if err := txStreamm.Send(req); err != nil {
+			break
+		}
 	}
 
 	summary, err := txStreamm.CloseAndRecv()
-	if err != nil {
-		st, _ := status.FromError(err)
-
-		log.Fatalln("[Fatal] error SummarizeTransactions :", st)
+	callmeta.FromContext(ctx).FromStream(txStreamm)
 
+	if err != nil {
+		return nil, rpcerr.New("BankAdapter.SummarizeTransactions", err)
 	}
 
-	log.Println("Summary:", summary)
+	return toTransactionSummary(summary), nil
 }
 
-func (a *BankAdapter) TransferMultiple(ctx context.Context, trf []dbank.TransferTransaction) {
+// TransferMultiple sends every transfer and delivers the status of each one
+// on the first channel.
+func (a *BankAdapter) TransferMultiple(ctx context.Context, trf []dbank.TransferTransaction) (
+	<-chan *dbank.TransferResult, <-chan error) {
 	trfStream, err := a.bankClient.TransferMultiple(ctx)
 
 	if err != nil {
-		log.Fatalln("[FATAL] Error on TransferMultiple :", err)
+		return stream.Fail[*dbank.TransferResult]("BankAdapter.TransferMultiple", err)
 	}
 
-	trfChan := make(chan struct{})
-
 	go func() {
 		for _, tt := range trf {
 			req := &bank.TransferRequest{
@@ -138,50 +126,82 @@ func (a *BankAdapter) TransferMultiple(ctx context.Context, trf []dbank.Transfer
 				Amount:            tt.Amount,
 			}
 
-			trfStream.Send(req)
+			if err := trfStream.Send(req); err != nil {
+				break
+			}
 		}
 
 		trfStream.CloseSend()
 	}()
 
-	go func() {
-		for {
-			res, err := trfStream.Recv()
+	recv := callmeta.Recv(callmeta.FromContext(ctx), trfStream, trfStream.Recv)
 
-			if err == io.EOF {
-				break
-			}
+	return stream.Receive(ctx, "BankAdapter.TransferMultiple", func() (*dbank.TransferResult, error) {
+		res, err := recv()
 
-			if err != nil {
-				handleTransferErrorGrpc(err)
-				break
-			} else {
-				log.Printf("Transfer status %v on %v\n", res.Status, res.Timestamp)
-			}
+		if err == io.EOF {
+			return nil, err
 		}
 
-		close(trfChan)
-	}()
+		if err != nil {
+			return nil, handleTransferErrorGrpc(err)
+		}
 
-	<-trfChan
+		return toTransferResult(res), nil
+	})
 }
 
-func handleTransferErrorGrpc(err error) {
-	st := status.Convert(err)
+// CreateAccount validates acct, so obviously bad requests never reach the
+// server, and opens the account.
+func (a *BankAdapter) CreateAccount(ctx context.Context, acct dbank.CreateAccount) (*dbank.Account, error) {
+	if err := acct.Validate(); err != nil {
+		return nil, err
+	}
 
-	log.Printf("Error %v on TransferMultiple : %v", st.Code(), st.Message())
+	req := &bank.CreateAccountRequest{
+		AccountName:          acct.AccountName,
+		Currency:             acct.Currency,
+		InitialDepositAmount: acct.InitialDepositAmount,
+	}
 
-	for _, detail := range st.Details() {
-		switch t := detail.(type) {
-		case *errdetails.PreconditionFailure:
-			for _, violation := range t.GetViolations() {
-				log.Println("[VIOLATION]", violation)
-			}
-		case *errdetails.ErrorInfo:
-			log.Printf("Error on : %v, with reason %v\n", t.Domain, t.Reason)
-			for k, v := range t.GetMetadata() {
-				log.Printf("  %v : %v\n", k, v)
-			}
+	res, err := a.bankClient.CreateAccount(ctx, req, callmeta.FromContext(ctx).CallOptions()...)
+	if err != nil {
+		return nil, handleCreateAccountErrorGrpc(err)
+	}
+
+	return toAccount(res), nil
+}
+
+// handleTransferErrorGrpc logs the error details sent by the server and
+// returns err wrapped for the caller.
+func handleTransferErrorGrpc(err error) error {
+	return logErrorDetails("TransferMultiple", rpcerr.New("BankAdapter.TransferMultiple", err))
+}
+
+// handleCreateAccountErrorGrpc logs the error details sent by the server and
+// returns err wrapped for the caller.
+func handleCreateAccountErrorGrpc(err error) error {
+	return logErrorDetails("CreateAccount", rpcerr.New("BankAdapter.CreateAccount", err))
+}
+
+func logErrorDetails(method string, err error) error {
+	var e *rpcerr.Error
+	if !errors.As(err, &e) {
+		return err
+	}
+
+	log.Printf("Error %v on %v : %v", e.Code(), method, e.GRPCStatus().Message())
+
+	for _, violation := range e.Violations() {
+		log.Println("[VIOLATION]", violation)
+	}
+
+	if info := e.ErrorInfo(); info != nil {
+		log.Printf("Error on : %v, with reason %v\n", info.Domain, info.Reason)
+		for k, v := range info.GetMetadata() {
+			log.Printf("  %v : %v\n", k, v)
 		}
 	}
+
+	return err
 }
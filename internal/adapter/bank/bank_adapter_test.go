@@ -0,0 +1,290 @@
+package bank
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fbriansyah/my-grpc-go-client/internal/adapter/rpcerr"
+	dbank "github.com/fbriansyah/my-grpc-go-client/internal/application/domain/bank"
+	"github.com/fbriansyah/my-grpc-go-client/internal/fakeserver"
+	"github.com/fbriansyah/my-grpc-go-client/internal/port/portmock"
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/bank"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestAdapter(t *testing.T) (*fakeserver.Server, *BankAdapter) {
+	t.Helper()
+
+	srv, conn := fakeserver.Start(t)
+
+	adapter, err := NewBankAdapter(conn)
+	if err != nil {
+		t.Fatalf("NewBankAdapter: %v", err)
+	}
+
+	return srv, adapter
+}
+
+func TestGetCurrentBalance(t *testing.T) {
+	_, adapter := newTestAdapter(t)
+
+	balance, err := adapter.GetCurrentBalance(context.Background(), "7835697001")
+	if err != nil {
+		t.Fatalf("GetCurrentBalance: %v", err)
+	}
+
+	if balance.Amount != fakeserver.Balance || !balance.Date.Equal(fakeserver.Day) {
+		t.Errorf("balance = %+v, want %v on %v", balance, fakeserver.Balance, fakeserver.Day)
+	}
+}
+
+func TestFetchExchangeRates(t *testing.T) {
+	_, adapter := newTestAdapter(t)
+
+	rates, errs := adapter.FetchExchangeRates(context.Background(), "USD", "IDR")
+
+	n := 0
+	for rate := range rates {
+		n++
+
+		if rate.FromCurrency != "USD" || rate.ToCurrency != "IDR" || rate.Rate != fakeserver.Rate {
+			t.Errorf("rate = %+v, want USD to IDR at %v", rate, fakeserver.Rate)
+		}
+	}
+
+	if err := <-errs; err != nil {
+		t.Fatalf("stream ended with error: %v", err)
+	}
+
+	if n != fakeserver.StreamLength {
+		t.Errorf("got %d rates, want %d", n, fakeserver.StreamLength)
+	}
+}
+
+func TestSummarizeTransactions(t *testing.T) {
+	_, adapter := newTestAdapter(t)
+
+	tx := []*dbank.Transaction{
+		{TransactionType: dbank.TransactionTypeIn, Amount: 100},
+		{TransactionType: dbank.TransactionTypeOut, Amount: 30},
+		{TransactionType: dbank.TransactionTypeIn, Amount: 5},
+	}
+
+	summary, err := adapter.SummarizeTransactions(context.Background(), "7835697001", tx)
+	if err != nil {
+		t.Fatalf("SummarizeTransactions: %v", err)
+	}
+
+	want := &dbank.TransactionSummary{
+		AccountNumber:   "7835697001",
+		SumAmountIn:     105,
+		SumAmountOut:    30,
+		SumTotal:        75,
+		TransactionDate: fakeserver.Day,
+	}
+	if *summary != *want {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+}
+
+func TestTransferMultiple(t *testing.T) {
+	_, adapter := newTestAdapter(t)
+
+	trf := []dbank.TransferTransaction{
+		{FromAccountNumber: "1", ToAccountNumber: "2", Currency: "IDR", Amount: 10},
+		{FromAccountNumber: "2", ToAccountNumber: "3", Currency: "IDR", Amount: 20},
+	}
+
+	results, errs := adapter.TransferMultiple(context.Background(), trf)
+
+	i := 0
+	for res := range results {
+		if res.FromAccountNumber != trf[i].FromAccountNumber || res.Amount != trf[i].Amount ||
+			res.Status != dbank.TransferStatusSuccess || !res.Timestamp.Equal(fakeserver.Day) {
+			t.Errorf("result %d = %+v, want success of %+v", i, res, trf[i])
+		}
+		i++
+	}
+
+	if err := <-errs; err != nil {
+		t.Fatalf("stream ended with error: %v", err)
+	}
+
+	if i != len(trf) {
+		t.Errorf("got %d results, want %d", i, len(trf))
+	}
+}
+
+func TestTransferMultipleErrorDetails(t *testing.T) {
+	srv, adapter := newTestAdapter(t)
+
+	st, err := status.New(codes.FailedPrecondition, "insufficient balance").WithDetails(
+		&errdetails.PreconditionFailure{Violations: []*errdetails.PreconditionFailure_Violation{
+			{Type: "INSUFFICIENT_BALANCE", Subject: "1", Description: "balance is too low"},
+		}},
+	)
+	if err != nil {
+		t.Fatalf("WithDetails: %v", err)
+	}
+	srv.Bank.On("TransferMultiple", fakeserver.Behavior{Err: st.Err()})
+
+	results, errs := adapter.TransferMultiple(context.Background(),
+		[]dbank.TransferTransaction{{FromAccountNumber: "1", ToAccountNumber: "2", Currency: "IDR", Amount: 10}})
+	for range results {
+		t.Error("got a result, want none")
+	}
+
+	var e *rpcerr.Error
+	if err := <-errs; !errors.As(err, &e) {
+		t.Fatalf("error %v is not a *rpcerr.Error", err)
+	}
+
+	if e.Code() != codes.FailedPrecondition {
+		t.Errorf("code = %v, want %v", e.Code(), codes.FailedPrecondition)
+	}
+
+	if v := e.Violations(); len(v) != 1 || v[0].Type != "INSUFFICIENT_BALANCE" {
+		t.Errorf("violations = %v, want one INSUFFICIENT_BALANCE", v)
+	}
+}
+
+func TestCreateAccount(t *testing.T) {
+	srv, adapter := newTestAdapter(t)
+
+	acct, err := adapter.CreateAccount(context.Background(),
+		dbank.CreateAccount{AccountName: "Budi", Currency: "IDR", InitialDepositAmount: 100})
+	if err != nil {
+		t.Fatalf("CreateAccount: %v", err)
+	}
+
+	if acct.AccountUUID == "" {
+		t.Error("AccountUUID is empty")
+	}
+
+	if calls := srv.Bank.Calls("CreateAccount"); len(calls) != 1 {
+		t.Errorf("server got %d calls, want 1", len(calls))
+	}
+}
+
+func TestCreateAccountInvalid(t *testing.T) {
+	srv, adapter := newTestAdapter(t)
+
+	_, err := adapter.CreateAccount(context.Background(), dbank.CreateAccount{Currency: "idr"})
+	if !errors.Is(err, dbank.ErrInvalidRequest) {
+		t.Fatalf("error = %v, want %v", err, dbank.ErrInvalidRequest)
+	}
+
+	if calls := srv.Bank.Calls("CreateAccount"); len(calls) != 0 {
+		t.Errorf("server got %d calls, want none", len(calls))
+	}
+}
+
+func TestSummarizeTransactionsRequests(t *testing.T) {
+	txStream := &portmock.ClientStream[*bank.Transaction, *bank.TransactionSummary]{
+		Responses: []*bank.TransactionSummary{{AccountNumber: "7835697001"}},
+	}
+
+	adapter, _ := NewBankAdapterWithClient(&portmock.BankClient{
+		SummarizeTransactionsFunc: func(ctx context.Context,
+			opts ...grpc.CallOption) (bank.BankService_SummarizeTransactionsClient, error) {
+			return txStream, nil
+		},
+	})
+
+	tx := []*dbank.Transaction{
+		{TransactionType: dbank.TransactionTypeIn, Amount: 100, Notes: "salary"},
+		{TransactionType: dbank.TransactionTypeOut, Amount: 30},
+		{TransactionType: "REFUND", Amount: 5},
+	}
+
+	summary, err := adapter.SummarizeTransactions(context.Background(), "7835697001", tx)
+	if err != nil {
+		t.Fatalf("SummarizeTransactions: %v", err)
+	}
+
+	if !summary.TransactionDate.IsZero() {
+		t.Errorf("TransactionDate = %v, want the zero time for an unset date", summary.TransactionDate)
+	}
+
+	wantTypes := []bank.TransactionType{
+		bank.TransactionType_TRANSACTION_TYPE_IN,
+		bank.TransactionType_TRANSACTION_TYPE_OUT,
+		bank.TransactionType_TRANSACTION_TYPE_UNSPECIFIED,
+	}
+
+	sent := txStream.Sent()
+	if len(sent) != len(tx) {
+		t.Fatalf("sent %d transactions, want %d", len(sent), len(tx))
+	}
+
+	for i, req := range sent {
+		if req.AccountNumber != "7835697001" || req.Type != wantTypes[i] ||
+			req.Amount != tx[i].Amount || req.Notes != tx[i].Notes {
+			t.Errorf("request %d = %v, want %+v of type %v", i, req, tx[i], wantTypes[i])
+		}
+	}
+}
+
+func TestTransferMultipleStatuses(t *testing.T) {
+	trfStream := &portmock.ClientStream[*bank.TransferRequest, *bank.TransferResponse]{
+		Responses: []*bank.TransferResponse{
+			{Status: bank.TransferStatus_TRANSFER_STATUS_SUCCESS},
+			{Status: bank.TransferStatus_TRANSFER_STATUS_FAILED},
+			{},
+		},
+	}
+
+	adapter, _ := NewBankAdapterWithClient(&portmock.BankClient{
+		TransferMultipleFunc: func(ctx context.Context,
+			opts ...grpc.CallOption) (bank.BankService_TransferMultipleClient, error) {
+			return trfStream, nil
+		},
+	})
+
+	results, errs := adapter.TransferMultiple(context.Background(), nil)
+
+	var got []string
+	for res := range results {
+		got = append(got, res.Status)
+	}
+
+	if err := <-errs; err != nil {
+		t.Fatalf("stream ended with error: %v", err)
+	}
+
+	want := []string{dbank.TransferStatusSuccess, dbank.TransferStatusFailed, dbank.TransferStatusUnspecified}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+}
+
+func TestCreateAccountRequest(t *testing.T) {
+	var got *bank.CreateAccountRequest
+
+	adapter, _ := NewBankAdapterWithClient(&portmock.BankClient{
+		CreateAccountFunc: func(ctx context.Context, in *bank.CreateAccountRequest,
+			opts ...grpc.CallOption) (*bank.CreateAccountResponse, error) {
+			got = in
+			return &bank.CreateAccountResponse{AccountUuid: "b3f1"}, nil
+		},
+	})
+
+	acct, err := adapter.CreateAccount(context.Background(),
+		dbank.CreateAccount{AccountName: "Budi", Currency: "USD", InitialDepositAmount: 25})
+	if err != nil {
+		t.Fatalf("CreateAccount: %v", err)
+	}
+
+	if acct.AccountUUID != "b3f1" {
+		t.Errorf("AccountUUID = %q, want %q", acct.AccountUUID, "b3f1")
+	}
+
+	if got.AccountName != "Budi" || got.Currency != "USD" || got.InitialDepositAmount != 25 {
+		t.Errorf("request = %v, want Budi, USD, 25", got)
+	}
+}
@@ -0,0 +1,104 @@
+package bank
+
+import (
+	"time"
+
+	dbank "github.com/fbriansyah/my-grpc-go-client/internal/application/domain/bank"
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/bank"
+	"google.golang.org/genproto/googleapis/type/date"
+	"google.golang.org/genproto/googleapis/type/datetime"
+)
+
+func toProtoTransactionType(ttype string) bank.TransactionType {
+	switch ttype {
+	case dbank.TransactionTypeIn:
+		return bank.TransactionType_TRANSACTION_TYPE_IN
+	case dbank.TransactionTypeOut:
+		return bank.TransactionType_TRANSACTION_TYPE_OUT
+	default:
+		return bank.TransactionType_TRANSACTION_TYPE_UNSPECIFIED
+	}
+}
+
+func toBalance(res *bank.CurrentBalanceResponse) *dbank.Balance {
+	return &dbank.Balance{
+		Amount: res.GetAmount(),
+		Date:   fromDate(res.GetCurrentDate()),
+	}
+}
+
+func toExchangeRate(res *bank.ExchangeRateResponse) *dbank.ExchangeRate {
+	return &dbank.ExchangeRate{
+		FromCurrency: res.GetFromCurrency(),
+		ToCurrency:   res.GetToCurrency(),
+		Rate:         res.GetRate(),
+		Timestamp:    res.GetTimestamp(),
+	}
+}
+
+func toTransactionSummary(res *bank.TransactionSummary) *dbank.TransactionSummary {
+	return &dbank.TransactionSummary{
+		AccountNumber:   res.GetAccountNumber(),
+		SumAmountIn:     res.GetSumAmountIn(),
+		SumAmountOut:    res.GetSumAmountOut(),
+		SumTotal:        res.GetSumTotal(),
+		TransactionDate: fromDate(res.GetTransactionDate()),
+	}
+}
+
+func toTransferResult(res *bank.TransferResponse) *dbank.TransferResult {
+	status := dbank.TransferStatusUnspecified
+
+	switch res.GetStatus() {
+	case bank.TransferStatus_TRANSFER_STATUS_SUCCESS:
+		status = dbank.TransferStatusSuccess
+	case bank.TransferStatus_TRANSFER_STATUS_FAILED:
+		status = dbank.TransferStatusFailed
+	}
+
+	return &dbank.TransferResult{
+		FromAccountNumber: res.GetFromAccountNumber(),
+		ToAccountNumber:   res.GetToAccountNumber(),
+		Currency:          res.GetCurrency(),
+		Amount:            res.GetAmount(),
+		Status:            status,
+		Timestamp:         fromDateTime(res.GetTimestamp()),
+	}
+}
+
+func toAccount(res *bank.CreateAccountResponse) *dbank.Account {
+	return &dbank.Account{
+		AccountUUID: res.GetAccountUuid(),
+	}
+}
+
+// fromDate returns midnight UTC of d, or the zero time when d is unset.
+func fromDate(d *date.Date) time.Time {
+	if d == nil {
+		return time.Time{}
+	}
+
+	return time.Date(int(d.GetYear()), time.Month(d.GetMonth()), int(d.GetDay()), 0, 0, 0, 0, time.UTC)
+}
+
+// fromDateTime returns dt in its UTC offset or time zone, falling back to
+// UTC when neither is set or the zone is unknown; the zero time is returned
+// when dt is unset.
+func fromDateTime(dt *datetime.DateTime) time.Time {
+	if dt == nil {
+		return time.Time{}
+	}
+
+	loc := time.UTC
+
+	if offset := dt.GetUtcOffset(); offset != nil {
+		loc = time.FixedZone("", int(offset.AsDuration().Seconds()))
+	} else if tz := dt.GetTimeZone(); tz != nil {
+		if l, err := time.LoadLocation(tz.GetId()); err == nil {
+			loc = l
+		}
+	}
+
+	return time.Date(int(dt.GetYear()), time.Month(dt.GetMonth()), int(dt.GetDay()),
+		int(dt.GetHours()), int(dt.GetMinutes()), int(dt.GetSeconds()), int(dt.GetNanos()), loc)
+}
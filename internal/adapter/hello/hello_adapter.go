@@ -2,10 +2,11 @@ package hello
 
 import (
 	"context"
-	"io"
-	"log"
 	"time"
 
+	"github.com/fbriansyah/my-grpc-go-client/internal/adapter/rpcerr"
+	"github.com/fbriansyah/my-grpc-go-client/internal/adapter/stream"
+	"github.com/fbriansyah/my-grpc-go-client/internal/callmeta"
 	"github.com/fbriansyah/my-grpc-go-client/internal/port"
 	"github.com/fbriansyah/my-grpc-proto/protogen/go/hello"
 	"google.golang.org/grpc"
@@ -18,54 +19,50 @@ type HelloAdapter struct {
 func NewHelloAdapter(conn *grpc.ClientConn) (*HelloAdapter, error) {
 	client := hello.NewHelloServiceClient(conn)
 
+	return NewHelloAdapterWithClient(client)
+}
+
+// NewHelloAdapterWithClient builds the adapter on any client, e.g. a
+// portmock.HelloClient in tests.
+func NewHelloAdapterWithClient(client port.HelloClientPort) (*HelloAdapter, error) {
 	return &HelloAdapter{
 		helloClient: client,
 	}, nil
 }
 
 func (a *HelloAdapter) SayHello(ctx context.Context, name string) (*hello.HelloResponse, error) {
-	helloRequest := &hello.HelloRequest{Name: "Rian", Age: 19}
+	helloRequest := &hello.HelloRequest{Name: name}
 
-	greet, err := a.helloClient.SayHello(ctx, helloRequest)
+	greet, err := a.helloClient.SayHello(ctx, helloRequest, callmeta.FromContext(ctx).CallOptions()...)
 
 	if err != nil {
-		log.Fatalln(err)
+		return nil, rpcerr.New("HelloAdapter.SayHello", err)
 	}
 
 	return greet, nil
 }
 
-func (a *HelloAdapter) SayManyHello(ctx context.Context, name string) {
+// SayManyHello delivers every greeting streamed by the server on the first
+// channel.
+func (a *HelloAdapter) SayManyHello(ctx context.Context, name string) (<-chan *hello.HelloResponse, <-chan error) {
 	helloRequest := &hello.HelloRequest{
-		Name: "Feb",
+		Name: name,
 	}
 
 	greetStream, err := a.helloClient.SayManyHello(ctx, helloRequest)
 	if err != nil {
-		log.Fatalln(err)
-	}
-
-	for {
-		greet, err := greetStream.Recv()
-
-		if err == io.EOF {
-			break
-		}
-
-		if err != nil {
-			log.Fatalln(err)
-		}
-
-		log.Println(greet.Greet)
+		return stream.Fail[*hello.HelloResponse]("HelloAdapter.SayManyHello", err)
 	}
 
+	return stream.Receive(ctx, "HelloAdapter.SayManyHello",
+		callmeta.Recv(callmeta.FromContext(ctx), greetStream, greetStream.Recv))
 }
 
-func (a *HelloAdapter) SayHelloToEveryone(ctx context.Context, names []string) {
+func (a *HelloAdapter) SayHelloToEveryone(ctx context.Context, names []string) (*hello.HelloResponse, error) {
 	greetStream, err := a.helloClient.SayHelloToEveryone(ctx)
 
 	if err != nil {
-		log.Fatalln("[SayHelloToEveryone]", err)
+		return nil, rpcerr.New("HelloAdapter.SayHelloToEveryone", err)
 	}
 
 	for _, name := range names {
@@ -73,53 +70,42 @@ func (a *HelloAdapter) SayHelloToEveryone(ctx context.Context, names []string) {
 			Name: name,
 		}
 
-		greetStream.Send(req)
+		if err := greetStream.Send(req); err != nil {
+			break
+		}
 		time.Sleep(500 * time.Millisecond)
 	}
 
 	res, err := greetStream.CloseAndRecv()
+	callmeta.FromContext(ctx).FromStream(greetStream)
+
 	if err != nil {
-		log.Fatalln(err)
+		return nil, rpcerr.New("HelloAdapter.SayHelloToEveryone", err)
 	}
 
-	log.Println(res.Greet)
+	return res, nil
 }
 
-func (a *HelloAdapter) SayHelloContinuous(ctx context.Context, names []string) {
+// SayHelloContinuous sends one request per name and delivers every greeting
+// on the first channel.
+func (a *HelloAdapter) SayHelloContinuous(ctx context.Context, names []string) (<-chan *hello.HelloResponse, <-chan error) {
 	greetStream, err := a.helloClient.SayHelloContinuous(ctx)
 	if err != nil {
-		log.Fatalln("[SayHelloContinuous]", err)
+		return stream.Fail[*hello.HelloResponse]("HelloAdapter.SayHelloContinuous", err)
 	}
 
-	greetChan := make(chan struct{})
-
 	go func() {
-
 		for _, name := range names {
 			req := &hello.HelloRequest{Name: name}
 
-			greetStream.Send(req)
-		}
-
-		greetStream.CloseSend()
-	}()
-
-	go func() {
-		for {
-			greet, err := greetStream.Recv()
-
-			if err == io.EOF {
+			if err := greetStream.Send(req); err != nil {
 				break
 			}
-
-			if err != nil {
-				log.Fatalln("[SayHelloContinuous]", err)
-			}
-
-			log.Println(greet.Greet)
 		}
-		close(greetChan)
+
+		greetStream.CloseSend()
 	}()
 
-	<-greetChan
+	return stream.Receive(ctx, "HelloAdapter.SayHelloContinuous",
+		callmeta.Recv(callmeta.FromContext(ctx), greetStream, greetStream.Recv))
 }
@@ -0,0 +1,159 @@
+package hello
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fbriansyah/my-grpc-go-client/internal/adapter/rpcerr"
+	"github.com/fbriansyah/my-grpc-go-client/internal/fakeserver"
+	"github.com/fbriansyah/my-grpc-go-client/internal/port/portmock"
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/hello"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestAdapter(t *testing.T) (*fakeserver.Server, *HelloAdapter) {
+	t.Helper()
+
+	srv, conn := fakeserver.Start(t)
+
+	adapter, err := NewHelloAdapter(conn)
+	if err != nil {
+		t.Fatalf("NewHelloAdapter: %v", err)
+	}
+
+	return srv, adapter
+}
+
+func collect(msgs <-chan *hello.HelloResponse, errs <-chan error) ([]string, error) {
+	var got []string
+	for msg := range msgs {
+		got = append(got, msg.Greet)
+	}
+
+	return got, <-errs
+}
+
+func TestSayHello(t *testing.T) {
+	_, adapter := newTestAdapter(t)
+
+	res, err := adapter.SayHello(context.Background(), "Budi")
+	if err != nil {
+		t.Fatalf("SayHello: %v", err)
+	}
+
+	if res.Greet != "Hello Budi" {
+		t.Errorf("Greet = %q, want %q", res.Greet, "Hello Budi")
+	}
+}
+
+func TestSayHelloError(t *testing.T) {
+	srv, adapter := newTestAdapter(t)
+	srv.Hello.On("SayHello", fakeserver.Behavior{Err: status.Error(codes.InvalidArgument, "no name")})
+
+	_, err := adapter.SayHello(context.Background(), "")
+	if got := rpcerr.Code(err); got != codes.InvalidArgument {
+		t.Fatalf("code = %v, want %v", got, codes.InvalidArgument)
+	}
+}
+
+func TestSayManyHello(t *testing.T) {
+	_, adapter := newTestAdapter(t)
+
+	got, err := collect(adapter.SayManyHello(context.Background(), "Budi"))
+	if err != nil {
+		t.Fatalf("stream ended with error: %v", err)
+	}
+
+	want := []string{"Hello Budi 1", "Hello Budi 2", "Hello Budi 3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSayHelloToEveryone(t *testing.T) {
+	_, adapter := newTestAdapter(t)
+
+	res, err := adapter.SayHelloToEveryone(context.Background(), []string{"Budi", "Ani"})
+	if err != nil {
+		t.Fatalf("SayHelloToEveryone: %v", err)
+	}
+
+	if res.Greet != "Hello Budi, Ani" {
+		t.Errorf("Greet = %q, want %q", res.Greet, "Hello Budi, Ani")
+	}
+}
+
+func TestSayHelloContinuous(t *testing.T) {
+	_, adapter := newTestAdapter(t)
+
+	got, err := collect(adapter.SayHelloContinuous(context.Background(), []string{"Budi", "Ani"}))
+	if err != nil {
+		t.Fatalf("stream ended with error: %v", err)
+	}
+
+	want := []string{"Hello Budi", "Hello Ani"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSayHelloToEveryoneStopsOnSendError(t *testing.T) {
+	greetStream := &portmock.ClientStream[*hello.HelloRequest, *hello.HelloResponse]{
+		SendErr: io.EOF,
+		Err:     status.Error(codes.Unavailable, "connection lost"),
+	}
+
+	adapter, _ := NewHelloAdapterWithClient(&portmock.HelloClient{
+		SayHelloToEveryoneFunc: func(ctx context.Context,
+			opts ...grpc.CallOption) (hello.HelloService_SayHelloToEveryoneClient, error) {
+			return greetStream, nil
+		},
+	})
+
+	_, err := adapter.SayHelloToEveryone(context.Background(), []string{"Budi", "Ani"})
+	if got := rpcerr.Code(err); got != codes.Unavailable {
+		t.Fatalf("code = %v, want the %v returned by CloseAndRecv", got, codes.Unavailable)
+	}
+
+	if !greetStream.SendClosed() {
+		t.Error("stream was not closed")
+	}
+}
+
+func TestSayHelloContinuousSendsEveryName(t *testing.T) {
+	greetStream := &portmock.ClientStream[*hello.HelloRequest, *hello.HelloResponse]{
+		Responses: []*hello.HelloResponse{{Greet: "Hello Budi"}},
+	}
+
+	adapter, _ := NewHelloAdapterWithClient(&portmock.HelloClient{
+		SayHelloContinuousFunc: func(ctx context.Context,
+			opts ...grpc.CallOption) (hello.HelloService_SayHelloContinuousClient, error) {
+			return greetStream, nil
+		},
+	})
+
+	got, err := collect(adapter.SayHelloContinuous(context.Background(), []string{"Budi", "Ani"}))
+	if err != nil || len(got) != 1 {
+		t.Fatalf("got %v, %v, want the scripted greeting", got, err)
+	}
+
+	// the sender runs on its own goroutine, the stream may end first
+	deadline := time.Now().Add(time.Second)
+	for !greetStream.SendClosed() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	var names []string
+	for _, req := range greetStream.Sent() {
+		names = append(names, req.Name)
+	}
+
+	if want := []string{"Budi", "Ani"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("sent %v, want %v", names, want)
+	}
+}
@@ -2,9 +2,10 @@ package resiliency
 
 import (
 	"context"
-	"io"
-	"log"
 
+	"github.com/fbriansyah/my-grpc-go-client/internal/adapter/rpcerr"
+	"github.com/fbriansyah/my-grpc-go-client/internal/adapter/stream"
+	"github.com/fbriansyah/my-grpc-go-client/internal/callmeta"
 	"github.com/fbriansyah/my-grpc-go-client/internal/port"
 	resl "github.com/fbriansyah/my-grpc-proto/protogen/go/resiliency"
 	"google.golang.org/grpc"
@@ -17,9 +18,19 @@ type ResiliencyAdapter struct {
 
 func NewResiliencyAdapter(conn *grpc.ClientConn) (*ResiliencyAdapter, error) {
 	client := resl.NewResiliencyServiceClient(conn)
+	metadataClient := resl.NewResiliencyWithMetadataServiceClient(conn)
 
+	return NewResiliencyAdapterWithClients(client, metadataClient)
+}
+
+// NewResiliencyAdapterWithClients builds the adapter on any clients, e.g.
+// portmock.ResiliencyClient and portmock.ResiliencyWithMetadataClient in
+// tests.
+func NewResiliencyAdapterWithClients(client port.ResiliencyClietnPort,
+	metadataClient port.ResiliencyWithMetadataClientPort) (*ResiliencyAdapter, error) {
 	return &ResiliencyAdapter{
-		resiliencyClient: client,
+		resiliencyClient:             client,
+		resiliencyWithMetadataClient: metadataClient,
 	}, nil
 }
 
@@ -30,16 +41,18 @@ func (a *ResiliencyAdapter) UnaryResiliency(ctx context.Context, minDelay int32,
 		StatusCodes:    statusCodes,
 	}
 
-	res, err := a.resiliencyClient.UnaryResiliency(ctx, req)
+	res, err := a.resiliencyClient.UnaryResiliency(ctx, req, callmeta.FromContext(ctx).CallOptions()...)
 	if err != nil {
-		log.Println("Error on UnaryResiliency :", err)
-		return nil, err
+		return nil, rpcerr.New("ResiliencyAdapter.UnaryResiliency", err)
 	}
 
 	return res, nil
 }
 
-func (a *ResiliencyAdapter) ServerStreamingResiliency(ctx context.Context, minDelay int32, maxDelay int32, statusCodes []uint32) {
+// ServerStreamingResiliency delivers every response streamed by the server
+// on the first channel.
+func (a *ResiliencyAdapter) ServerStreamingResiliency(ctx context.Context, minDelay int32, maxDelay int32,
+	statusCodes []uint32) (<-chan *resl.ResiliencyResponse, <-chan error) {
 	req := &resl.ResiliencyRequest{
 		MinDelaySecond: minDelay,
 		MaxDelaySecond: maxDelay,
@@ -48,27 +61,17 @@ func (a *ResiliencyAdapter) ServerStreamingResiliency(ctx context.Context, minDe
 
 	reslStream, err := a.resiliencyClient.ServerStreamingResiliency(ctx, req)
 	if err != nil {
-		log.Fatalln("Error on ServerStreamingResiliency :", err)
+		return stream.Fail[*resl.ResiliencyResponse]("ResiliencyAdapter.ServerStreamingResiliency", err)
 	}
 
-	for {
-		res, err := reslStream.Recv()
-		if err == io.EOF {
-			break
-		}
-
-		if err != nil {
-			log.Fatalln("Error on ServerStreamingResiliency :", err)
-		}
-
-		log.Println(res.DummyString)
-	}
+	return stream.Receive(ctx, "ResiliencyAdapter.ServerStreamingResiliency",
+		callmeta.Recv(callmeta.FromContext(ctx), reslStream, reslStream.Recv))
 }
 
-func (a *ResiliencyAdapter) ClientStreamingResiliency(ctx context.Context, minDelay int32, maxDelay int32, statusCodes []uint32, count int) {
+func (a *ResiliencyAdapter) ClientStreamingResiliency(ctx context.Context, minDelay int32, maxDelay int32, statusCodes []uint32, count int) (*resl.ResiliencyResponse, error) {
 	reslStream, err := a.resiliencyClient.ClientStreamingResiliency(ctx)
 	if err != nil {
-		log.Fatalln("Error on ClientStreamingResiliency :", err)
+		return nil, rpcerr.New("ResiliencyAdapter.ClientStreamingResiliency", err)
 	}
 
 	for i := 1; i <= count; i++ {
@@ -77,25 +80,31 @@ func (a *ResiliencyAdapter) ClientStreamingResiliency(ctx context.Context, minDe
 			MaxDelaySecond: maxDelay,
 			StatusCodes:    statusCodes,
 		}
-		reslStream.Send(req)
+
+		if err := reslStream.Send(req); err != nil {
+			break
+		}
 	}
 
 	res, err := reslStream.CloseAndRecv()
+	callmeta.FromContext(ctx).FromStream(reslStream)
+
 	if err != nil {
-		log.Fatalln("Error on ClientStreamingResiliency :", err)
+		return nil, rpcerr.New("ResiliencyAdapter.ClientStreamingResiliency", err)
 	}
 
-	log.Println(res.DummyString)
+	return res, nil
 }
 
-func (a *ResiliencyAdapter) BiDirectionalResiliency(ctx context.Context, minDelay int32, maxDelay int32, statusCodes []uint32, count int) {
+// BiDirectionalResiliency sends count requests and delivers every response
+// on the first channel.
+func (a *ResiliencyAdapter) BiDirectionalResiliency(ctx context.Context, minDelay int32, maxDelay int32,
+	statusCodes []uint32, count int) (<-chan *resl.ResiliencyResponse, <-chan error) {
 	reslStream, err := a.resiliencyClient.BiDirectionalResiliency(ctx)
 	if err != nil {
-		log.Fatalln("Error on BiDirectionalResiliency :", err)
+		return stream.Fail[*resl.ResiliencyResponse]("ResiliencyAdapter.BiDirectionalResiliency", err)
 	}
 
-	reslChan := make(chan struct{})
-
 	go func() {
 		for i := 1; i <= count; i++ {
 			req := &resl.ResiliencyRequest{
@@ -103,27 +112,15 @@ func (a *ResiliencyAdapter) BiDirectionalResiliency(ctx context.Context, minDela
 				MaxDelaySecond: maxDelay,
 				StatusCodes:    statusCodes,
 			}
-			reslStream.Send(req)
-		}
-
-		reslStream.CloseSend()
-	}()
 
-	go func() {
-		for {
-			res, err := reslStream.Recv()
-			if err == io.EOF {
+			if err := reslStream.Send(req); err != nil {
 				break
 			}
-
-			if err != nil {
-				log.Fatalln("Error on BiDirectionalResiliency :", err)
-			}
-
-			log.Println(res.DummyString)
 		}
-		close(reslChan)
+
+		reslStream.CloseSend()
 	}()
 
-	<-reslChan
+	return stream.Receive(ctx, "ResiliencyAdapter.BiDirectionalResiliency",
+		callmeta.Recv(callmeta.FromContext(ctx), reslStream, reslStream.Recv))
 }
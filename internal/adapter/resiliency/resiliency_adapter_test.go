@@ -0,0 +1,207 @@
+package resiliency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fbriansyah/my-grpc-go-client/internal/adapter/rpcerr"
+	dresl "github.com/fbriansyah/my-grpc-go-client/internal/application/domain/resiliency"
+	"github.com/fbriansyah/my-grpc-go-client/internal/callmeta"
+	"github.com/fbriansyah/my-grpc-go-client/internal/fakeserver"
+	"github.com/fbriansyah/my-grpc-go-client/internal/port/portmock"
+	resl "github.com/fbriansyah/my-grpc-proto/protogen/go/resiliency"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newTestAdapter(t *testing.T, opts ...grpc.DialOption) (*fakeserver.Server, *ResiliencyAdapter) {
+	t.Helper()
+
+	srv, conn := fakeserver.Start(t, opts...)
+
+	adapter, err := NewResiliencyAdapter(conn)
+	if err != nil {
+		t.Fatalf("NewResiliencyAdapter: %v", err)
+	}
+
+	return srv, adapter
+}
+
+func collect(msgs <-chan *resl.ResiliencyResponse, errs <-chan error) ([]string, error) {
+	var got []string
+	for msg := range msgs {
+		got = append(got, msg.DummyString)
+	}
+
+	return got, <-errs
+}
+
+func TestUnaryResiliency(t *testing.T) {
+	_, adapter := newTestAdapter(t)
+
+	res, err := adapter.UnaryResiliency(context.Background(), 0, 0, []uint32{dresl.OK})
+	if err != nil {
+		t.Fatalf("UnaryResiliency: %v", err)
+	}
+
+	if want := "UnaryResiliency answer 0"; res.DummyString != want {
+		t.Errorf("DummyString = %q, want %q", res.DummyString, want)
+	}
+}
+
+func TestUnaryResiliencyStatusCodes(t *testing.T) {
+	_, adapter := newTestAdapter(t)
+	ctx := context.Background()
+
+	codesSeq := []uint32{dresl.NOT_FOUND, dresl.OK}
+
+	_, err := adapter.UnaryResiliency(ctx, 0, 0, codesSeq)
+	if got := rpcerr.Code(err); got != codes.NotFound {
+		t.Fatalf("first call code = %v, want %v", got, codes.NotFound)
+	}
+
+	if _, err := adapter.UnaryResiliency(ctx, 0, 0, codesSeq); err != nil {
+		t.Fatalf("second call: %v", err)
+	}
+}
+
+func TestUnaryResiliencyDeadline(t *testing.T) {
+	srv, adapter := newTestAdapter(t)
+	srv.Resiliency.On("UnaryResiliency", fakeserver.Behavior{Delay: time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := adapter.UnaryResiliency(ctx, 0, 0, []uint32{dresl.OK})
+	if got := rpcerr.Code(err); got != codes.DeadlineExceeded {
+		t.Fatalf("code = %v, want %v", got, codes.DeadlineExceeded)
+	}
+}
+
+func TestUnaryResiliencyCapture(t *testing.T) {
+	srv, adapter := newTestAdapter(t)
+	srv.Resiliency.On("UnaryResiliency", fakeserver.Behavior{
+		Header:  metadata.Pairs("x-request-id", "42"),
+		Trailer: metadata.Pairs("x-server-time", "7ms"),
+	})
+
+	ctx, capture := callmeta.WithCapture(context.Background())
+
+	if _, err := adapter.UnaryResiliency(ctx, 0, 0, []uint32{dresl.OK}); err != nil {
+		t.Fatalf("UnaryResiliency: %v", err)
+	}
+
+	if got := capture.Header.Get("x-request-id"); len(got) != 1 || got[0] != "42" {
+		t.Errorf("header x-request-id = %v, want [42]", got)
+	}
+
+	if got := capture.Trailer.Get("x-server-time"); len(got) != 1 || got[0] != "7ms" {
+		t.Errorf("trailer x-server-time = %v, want [7ms]", got)
+	}
+}
+
+func TestServerStreamingResiliency(t *testing.T) {
+	_, adapter := newTestAdapter(t)
+
+	got, err := collect(adapter.ServerStreamingResiliency(context.Background(), 0, 0, []uint32{dresl.OK}))
+	if err != nil {
+		t.Fatalf("stream ended with error: %v", err)
+	}
+
+	if len(got) != fakeserver.StreamLength {
+		t.Errorf("got %d responses %v, want %d", len(got), got, fakeserver.StreamLength)
+	}
+}
+
+func TestServerStreamingResiliencyFailsMidStream(t *testing.T) {
+	srv, adapter := newTestAdapter(t)
+	srv.Resiliency.On("ServerStreamingResiliency", fakeserver.Behavior{
+		Err:       status.Error(codes.Unavailable, "going away"),
+		FailAfter: 1,
+		Trailer:   metadata.Pairs("retry-after", "1s"),
+	})
+
+	ctx, capture := callmeta.WithCapture(context.Background())
+
+	got, err := collect(adapter.ServerStreamingResiliency(ctx, 0, 0, []uint32{dresl.OK}))
+	if len(got) != 1 {
+		t.Errorf("got %d responses %v before the failure, want 1", len(got), got)
+	}
+
+	if code := rpcerr.Code(err); code != codes.Unavailable {
+		t.Fatalf("code = %v, want %v", code, codes.Unavailable)
+	}
+
+	if got := capture.Trailer.Get("retry-after"); len(got) != 1 || got[0] != "1s" {
+		t.Errorf("trailer retry-after = %v, want [1s]", got)
+	}
+}
+
+func TestClientStreamingResiliency(t *testing.T) {
+	_, adapter := newTestAdapter(t)
+
+	res, err := adapter.ClientStreamingResiliency(context.Background(), 0, 0, []uint32{dresl.OK}, 3)
+	if err != nil {
+		t.Fatalf("ClientStreamingResiliency: %v", err)
+	}
+
+	if want := "ClientStreamingResiliency answer 0"; res.DummyString != want {
+		t.Errorf("DummyString = %q, want %q", res.DummyString, want)
+	}
+}
+
+func TestClientStreamingResiliencyError(t *testing.T) {
+	_, adapter := newTestAdapter(t)
+
+	_, err := adapter.ClientStreamingResiliency(context.Background(), 0, 0, []uint32{dresl.PERMISSION_DENIED}, 3)
+	if got := rpcerr.Code(err); got != codes.PermissionDenied {
+		t.Fatalf("code = %v, want %v", got, codes.PermissionDenied)
+	}
+}
+
+func TestBiDirectionalResiliency(t *testing.T) {
+	srv, adapter := newTestAdapter(t)
+
+	got, err := collect(adapter.BiDirectionalResiliency(context.Background(), 0, 0, []uint32{dresl.OK}, 4))
+	if err != nil {
+		t.Fatalf("stream ended with error: %v", err)
+	}
+
+	if len(got) != 4 {
+		t.Errorf("got %d responses %v, want 4", len(got), got)
+	}
+
+	if calls := srv.Resiliency.Calls("BiDirectionalResiliency"); len(calls) != 1 {
+		t.Errorf("server got %d calls, want 1", len(calls))
+	}
+}
+
+func TestResiliencyAdapterWithClients(t *testing.T) {
+	var got *resl.ResiliencyRequest
+
+	adapter, _ := NewResiliencyAdapterWithClients(&portmock.ResiliencyClient{
+		UnaryResiliencyFunc: func(ctx context.Context, in *resl.ResiliencyRequest,
+			opts ...grpc.CallOption) (*resl.ResiliencyResponse, error) {
+			got = in
+			return &resl.ResiliencyResponse{DummyString: "scripted"}, nil
+		},
+	}, &portmock.ResiliencyWithMetadataClient{})
+
+	res, err := adapter.UnaryResiliency(context.Background(), 1, 3, []uint32{dresl.OK, dresl.UNKNOWN})
+	if err != nil {
+		t.Fatalf("UnaryResiliency: %v", err)
+	}
+
+	if res.DummyString != "scripted" || got.MinDelaySecond != 1 || got.MaxDelaySecond != 3 || len(got.StatusCodes) != 2 {
+		t.Errorf("got %q for request %v", res.DummyString, got)
+	}
+
+	// methods without a func are not scripted
+	_, _, err = adapter.UnaryResiliencyWithMetadata(context.Background(), 0, 0, nil)
+	if code := rpcerr.Code(err); code != codes.Unimplemented {
+		t.Errorf("code = %v, want %v", code, codes.Unimplemented)
+	}
+}
@@ -2,177 +2,215 @@ package resiliency
 
 import (
 	"context"
-	"fmt"
-	"io"
-	"log"
-	"runtime"
 	"time"
 
+	"github.com/fbriansyah/my-grpc-go-client/internal/adapter/rpcerr"
+	"github.com/fbriansyah/my-grpc-go-client/internal/adapter/stream"
+	"github.com/fbriansyah/my-grpc-go-client/internal/callmeta"
 	resl "github.com/fbriansyah/my-grpc-proto/protogen/go/resiliency"
 	"github.com/google/uuid"
-	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 )
 
-func sampleRequestMetadata() metadata.MD {
-	md := map[string]string{
-		"grpc-client-time":  fmt.Sprintf(time.Now().Format("15:04:05")),
-		"grpc.client-os":    runtime.GOOS,
-		"grpc-request-uuid": uuid.New().String(),
+// MessageUUIDKey is the key of the UUIDs of the requests of a client or
+// bidi stream, one value per request in send order.
+const MessageUUIDKey = "grpc-message-uuid"
+
+// MessageMetadata identifies a single request sent on a call.
+type MessageMetadata struct {
+	// RequestUUID is sent to the server: it is the request UUID of the
+	// call for a unary or server stream call, which send a single request,
+	// and a value of MessageUUIDKey for the other streams. It is empty
+	// when the call sent no request UUID.
+	RequestUUID string
+	// ClientTime is when the request was sent, it is only known to the
+	// client.
+	ClientTime time.Time
+}
+
+// CallMetadata is the metadata exchanged on a *WithMetadata call.
+//
+// gRPC metadata belongs to the call, not to the messages, so the request
+// metadata is sent once when the call is opened. The requests of a client
+// or bidi stream are identified by the MessageUUIDKey values sent then, in
+// send order, which let the server logs tell them apart. Messages holds
+// the identifiers of the requests actually sent.
+//
+// The request metadata, added by the metadata interceptor, and the
+// response header and trailer are recorded in the embedded Metadata, which
+// is the capture set on ctx by callmeta.WithCapture when there is one.
+//
+// For streams returning channels, CallMetadata is filled in while the
+// stream runs and must only be read once the error channel is closed.
+type CallMetadata struct {
+	*callmeta.Metadata
+	Messages []MessageMetadata
+}
+
+// withMessageUUIDs returns ctx sending a new UUID for each of the count
+// requests of a stream, and the UUIDs.
+func withMessageUUIDs(ctx context.Context, count int) (context.Context, []string) {
+	if count <= 0 {
+		return ctx, nil
+	}
+
+	uuids := make([]string, count)
+	for i := range uuids {
+		uuids[i] = uuid.NewString()
 	}
-	return metadata.New(md)
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Append(MessageUUIDKey, uuids...)
+
+	return metadata.NewOutgoingContext(ctx, md), uuids
 }
 
-func sampleResponseMetadata(md metadata.MD) {
-	if md.Len() == 0 {
-		log.Println("Response metadata not found")
-	} else {
-		log.Println("Response metadata :")
-		for k, v := range md {
-			log.Printf("  %v : %v\n", k, v)
-		}
+// openCall returns a new CallMetadata recording into the capture of ctx,
+// setting one on ctx when there is none.
+func openCall(ctx context.Context) (context.Context, *CallMetadata) {
+	capture := callmeta.FromContext(ctx)
+	if capture == nil {
+		ctx, capture = callmeta.WithCapture(ctx)
 	}
+
+	return ctx, &CallMetadata{Metadata: capture}
 }
 
 func (a *ResiliencyAdapter) UnaryResiliencyWithMetadata(ctx context.Context, minDelaySecond int32,
-	maxDelaySecond int32, statusCodes []uint32) (*resl.ResiliencyResponse, error) {
-	ctx = metadata.NewOutgoingContext(ctx, sampleRequestMetadata())
+	maxDelaySecond int32, statusCodes []uint32) (*resl.ResiliencyResponse, *CallMetadata, error) {
+	ctx, callMd := openCall(ctx)
 
 	resiliencyRequest := &resl.ResiliencyRequest{
 		MinDelaySecond: minDelaySecond,
 		MaxDelaySecond: maxDelaySecond,
 		StatusCodes:    statusCodes,
 	}
+	sentAt := time.Now()
 
-	var responseMetadata metadata.MD
 	res, err := a.resiliencyWithMetadataClient.UnaryResiliencyWithMetadata(ctx,
-		resiliencyRequest, grpc.Header(&responseMetadata))
+		resiliencyRequest, callMd.CallOptions()...)
+
+	callMd.Messages = append(callMd.Messages, MessageMetadata{RequestUUID: callMd.RequestUUID(), ClientTime: sentAt})
 
 	if err != nil {
-		log.Println("Error on UnaryResiliencyWithMetadata :", err)
-		return nil, err
+		return nil, callMd, rpcerr.New("ResiliencyAdapter.UnaryResiliencyWithMetadata", err)
 	}
 
-	sampleResponseMetadata(responseMetadata)
-
-	return res, nil
+	return res, callMd, nil
 }
 
+// ServerStreamingResiliencyWithMetadata delivers every response streamed by
+// the server on the first channel.
 func (a *ResiliencyAdapter) ServerStreamingResiliencyWithMetadata(ctx context.Context, minDelaySecond int32,
-	maxDelaySecond int32, statusCodes []uint32) {
-	ctx = metadata.NewOutgoingContext(ctx, sampleRequestMetadata())
+	maxDelaySecond int32, statusCodes []uint32) (<-chan *resl.ResiliencyResponse, <-chan error, *CallMetadata) {
+	ctx, callMd := openCall(ctx)
+
 	resiliencyRequest := &resl.ResiliencyRequest{
 		MinDelaySecond: minDelaySecond,
 		MaxDelaySecond: maxDelaySecond,
 		StatusCodes:    statusCodes,
 	}
+	sentAt := time.Now()
 
 	reslStream, err := a.resiliencyWithMetadataClient.ServerStreamingResiliencyWithMetadata(
 		ctx, resiliencyRequest)
 
-	if err != nil {
-		log.Fatalln("Error on ServerStreamingResiliencyWithMetadata :", err)
-	}
+	callMd.Messages = append(callMd.Messages, MessageMetadata{RequestUUID: callMd.RequestUUID(), ClientTime: sentAt})
 
-	if responseMetadata, err := reslStream.Header(); err == nil {
-		sampleResponseMetadata(responseMetadata)
+	if err != nil {
+		reslChan, errChan := stream.Fail[*resl.ResiliencyResponse](
+			"ResiliencyAdapter.ServerStreamingResiliencyWithMetadata", err)
+		return reslChan, errChan, callMd
 	}
 
-	for {
-		res, err := reslStream.Recv()
+	reslChan, errChan := stream.Receive(ctx, "ResiliencyAdapter.ServerStreamingResiliencyWithMetadata",
+		callmeta.Recv(callMd.Metadata, reslStream, reslStream.Recv))
 
-		if err == io.EOF {
-			break
-		}
-
-		if err != nil {
-			log.Fatalln("Error on ServerStreamingResiliencyWithMetadata :", err)
-		}
-
-		log.Println(res.DummyString)
-	}
+	return reslChan, errChan, callMd
 }
 
 func (a *ResiliencyAdapter) ClientStreamingResiliencyWithMetadata(ctx context.Context, minDelaySecond int32,
-	maxDelaySecond int32, statusCodes []uint32, count int) {
+	maxDelaySecond int32, statusCodes []uint32, count int) (*resl.ResiliencyResponse, *CallMetadata, error) {
+	ctx, callMd := openCall(ctx)
+	ctx, uuids := withMessageUUIDs(ctx, count)
+
 	reslStream, err := a.resiliencyWithMetadataClient.ClientStreamingResiliencyWithMetadata(ctx)
 
 	if err != nil {
-		log.Fatalln("Error on ClientStreamingResiliencyWithMetadata :", err)
+		return nil, callMd, rpcerr.New("ResiliencyAdapter.ClientStreamingResiliencyWithMetadata", err)
 	}
 
 	for i := 1; i <= count; i++ {
-		ctx = metadata.NewOutgoingContext(ctx, sampleRequestMetadata())
-
 		resiliencyRequest := &resl.ResiliencyRequest{
 			MinDelaySecond: minDelaySecond,
 			MaxDelaySecond: maxDelaySecond,
 			StatusCodes:    statusCodes,
 		}
 
-		reslStream.Send(resiliencyRequest)
+		msgMd := MessageMetadata{RequestUUID: uuids[i-1], ClientTime: time.Now()}
+
+		if err := reslStream.Send(resiliencyRequest); err != nil {
+			break
+		}
+
+		callMd.Messages = append(callMd.Messages, msgMd)
 	}
 
 	res, err := reslStream.CloseAndRecv()
 
-	if err != nil {
-		log.Fatalln("Error on ClientStreamingResiliencyWithMetadata :", err)
-	}
+	callMd.FromStream(reslStream)
 
-	if responseMetadata, err := reslStream.Header(); err == nil {
-		sampleResponseMetadata(responseMetadata)
+	if err != nil {
+		return nil, callMd, rpcerr.New("ResiliencyAdapter.ClientStreamingResiliencyWithMetadata", err)
 	}
 
-	log.Println(res.DummyString)
+	return res, callMd, nil
 }
 
+// BiDirectionalResiliencyWithMetadata sends count requests and delivers
+// every response on the first channel.
 func (a *ResiliencyAdapter) BiDirectionalResiliencyWithMetadata(ctx context.Context, minDelaySecond int32,
-	maxDelaySecond int32, statusCodes []uint32, count int) {
+	maxDelaySecond int32, statusCodes []uint32, count int) (<-chan *resl.ResiliencyResponse, <-chan error, *CallMetadata) {
+	ctx, callMd := openCall(ctx)
+	ctx, uuids := withMessageUUIDs(ctx, count)
+
 	reslStream, err := a.resiliencyWithMetadataClient.BiDirectionalResiliencyWithMetadata(ctx)
 
 	if err != nil {
-		log.Fatalln("Error on BiDirectionalResiliencyWithMetadata :", err)
+		reslChan, errChan := stream.Fail[*resl.ResiliencyResponse](
+			"ResiliencyAdapter.BiDirectionalResiliencyWithMetadata", err)
+		return reslChan, errChan, callMd
 	}
 
-	if responseMetadata, err := reslStream.Header(); err == nil {
-		sampleResponseMetadata(responseMetadata)
-	}
-
-	reslChan := make(chan struct{})
+	sendDone := make(chan struct{})
 
 	go func() {
-		for i := 1; i <= count; i++ {
-			ctx = metadata.NewOutgoingContext(ctx, sampleRequestMetadata())
+		defer close(sendDone)
 
+		for i := 1; i <= count; i++ {
 			resiliencyRequest := &resl.ResiliencyRequest{
 				MinDelaySecond: minDelaySecond,
 				MaxDelaySecond: maxDelaySecond,
 				StatusCodes:    statusCodes,
 			}
 
-			reslStream.Send(resiliencyRequest)
-		}
-
-		reslStream.CloseSend()
-	}()
+			msgMd := MessageMetadata{RequestUUID: uuids[i-1], ClientTime: time.Now()}
 
-	go func() {
-		for {
-			res, err := reslStream.Recv()
-
-			if err == io.EOF {
+			if err := reslStream.Send(resiliencyRequest); err != nil {
 				break
 			}
 
-			if err != nil {
-				log.Fatalln("Error on BiDirectionalResiliencyWithMetadata :", err)
-			}
-
-			log.Println(res.DummyString)
+			callMd.Messages = append(callMd.Messages, msgMd)
 		}
 
-		close(reslChan)
+		reslStream.CloseSend()
 	}()
 
-	<-reslChan
+	reslChan, errChan := stream.Receive(ctx, "ResiliencyAdapter.BiDirectionalResiliencyWithMetadata",
+		callmeta.Recv(callMd.Metadata, reslStream, reslStream.Recv))
+
+	// the sending goroutine appends to callMd.Messages, so the end of the
+	// stream is only reported once it is done
+	return reslChan, stream.Finally(errChan, func() { <-sendDone }), callMd
 }
@@ -0,0 +1,164 @@
+package resiliency
+
+import (
+	"context"
+	"testing"
+
+	dresl "github.com/fbriansyah/my-grpc-go-client/internal/application/domain/resiliency"
+	"github.com/fbriansyah/my-grpc-go-client/internal/callmeta"
+	"github.com/fbriansyah/my-grpc-go-client/internal/fakeserver"
+	"github.com/fbriansyah/my-grpc-go-client/internal/interceptor"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func newMetadataTestAdapter(t *testing.T, method string, shape string) (*ResiliencyAdapter, *fakeserver.ResiliencyWithMetadata) {
+	t.Helper()
+
+	// the request metadata is added by the interceptor, as in the client
+	srv, adapter := newTestAdapter(t,
+		grpc.WithChainUnaryInterceptor(interceptor.MetadataUnaryClientInterceptor(interceptor.RequestUUID())),
+		grpc.WithChainStreamInterceptor(interceptor.MetadataStreamClientInterceptor(interceptor.RequestUUID())),
+	)
+	srv.ResiliencyWithMetadata.On(method, fakeserver.Behavior{
+		Header:  metadata.Pairs("server-shape", shape),
+		Trailer: metadata.Pairs("server-trailer", shape),
+	})
+
+	return adapter, srv.ResiliencyWithMetadata
+}
+
+// checkCallMetadata checks that the request metadata reached the server and
+// that the response header, trailer and message identifiers were captured.
+func checkCallMetadata(t *testing.T, srv *fakeserver.ResiliencyWithMetadata, method string,
+	callMd *CallMetadata, shape string, messages int) {
+	t.Helper()
+
+	want := callMd.Request.Get("grpc-request-uuid")
+	if len(want) != 1 {
+		t.Fatalf("request grpc-request-uuid = %v, want one value", want)
+	}
+
+	calls := srv.Calls(method)
+	if len(calls) != 1 {
+		t.Fatalf("server got %d calls, want 1", len(calls))
+	}
+
+	if got := calls[0].Metadata.Get("grpc-request-uuid"); len(got) != 1 || got[0] != want[0] {
+		t.Errorf("server got grpc-request-uuid %v, want %v", got, want)
+	}
+
+	if got := callMd.Header.Get("server-shape"); len(got) != 1 || got[0] != shape {
+		t.Errorf("header server-shape = %v, want [%v]", got, shape)
+	}
+
+	if got := callMd.Trailer.Get("server-trailer"); len(got) != 1 || got[0] != shape {
+		t.Errorf("trailer server-trailer = %v, want [%v]", got, shape)
+	}
+
+	if len(callMd.Messages) != messages {
+		t.Fatalf("got %d message identifiers, want %d", len(callMd.Messages), messages)
+	}
+
+	// a single request is identified by the request UUID of the call, the
+	// requests of a stream by the message UUIDs sent when it opened
+	sent := calls[0].Metadata.Get(MessageUUIDKey)
+	if shape == "unary" || shape == "server-stream" {
+		sent = calls[0].Metadata.Get("grpc-request-uuid")
+	}
+
+	if len(sent) != messages {
+		t.Fatalf("server got %d message identifiers %v, want %d", len(sent), sent, messages)
+	}
+
+	for i, msg := range callMd.Messages {
+		if msg.RequestUUID != sent[i] || msg.ClientTime.IsZero() {
+			t.Errorf("message %d identifier %+v, want the UUID %v the server got", i+1, msg, sent[i])
+		}
+	}
+}
+
+func TestUnaryResiliencyWithMetadata(t *testing.T) {
+	method := "UnaryResiliencyWithMetadata"
+	adapter, srv := newMetadataTestAdapter(t, method, "unary")
+
+	res, callMd, err := adapter.UnaryResiliencyWithMetadata(context.Background(), 0, 0, []uint32{dresl.OK})
+	if err != nil {
+		t.Fatalf("UnaryResiliencyWithMetadata: %v", err)
+	}
+
+	if want := method + " answer 0"; res.DummyString != want {
+		t.Errorf("DummyString = %q, want %q", res.DummyString, want)
+	}
+
+	checkCallMetadata(t, srv, method, callMd, "unary", 1)
+}
+
+func TestServerStreamingResiliencyWithMetadata(t *testing.T) {
+	method := "ServerStreamingResiliencyWithMetadata"
+	adapter, srv := newMetadataTestAdapter(t, method, "server-stream")
+
+	msgs, errs, callMd := adapter.ServerStreamingResiliencyWithMetadata(context.Background(), 0, 0, []uint32{dresl.OK})
+	got, err := collect(msgs, errs)
+	if err != nil {
+		t.Fatalf("stream ended with error: %v", err)
+	}
+
+	if len(got) != fakeserver.StreamLength {
+		t.Errorf("got %d responses %v, want %d", len(got), got, fakeserver.StreamLength)
+	}
+
+	checkCallMetadata(t, srv, method, callMd, "server-stream", 1)
+}
+
+func TestClientStreamingResiliencyWithMetadata(t *testing.T) {
+	method := "ClientStreamingResiliencyWithMetadata"
+	adapter, srv := newMetadataTestAdapter(t, method, "client-stream")
+
+	res, callMd, err := adapter.ClientStreamingResiliencyWithMetadata(context.Background(), 0, 0, []uint32{dresl.OK}, 4)
+	if err != nil {
+		t.Fatalf("ClientStreamingResiliencyWithMetadata: %v", err)
+	}
+
+	if want := method + " answer 0"; res.DummyString != want {
+		t.Errorf("DummyString = %q, want %q", res.DummyString, want)
+	}
+
+	checkCallMetadata(t, srv, method, callMd, "client-stream", 4)
+}
+
+func TestBiDirectionalResiliencyWithMetadata(t *testing.T) {
+	method := "BiDirectionalResiliencyWithMetadata"
+	adapter, srv := newMetadataTestAdapter(t, method, "bidi")
+
+	msgs, errs, callMd := adapter.BiDirectionalResiliencyWithMetadata(context.Background(), 0, 0, []uint32{dresl.OK}, 5)
+	got, err := collect(msgs, errs)
+	if err != nil {
+		t.Fatalf("stream ended with error: %v", err)
+	}
+
+	if len(got) != 5 {
+		t.Errorf("got %d responses %v, want 5", len(got), got)
+	}
+
+	checkCallMetadata(t, srv, method, callMd, "bidi", 5)
+}
+
+func TestCallMetadataUsesContextCapture(t *testing.T) {
+	adapter, _ := newMetadataTestAdapter(t, "ServerStreamingResiliencyWithMetadata", "server-stream")
+
+	ctx, capture := callmeta.WithCapture(context.Background())
+
+	msgs, errs, callMd := adapter.ServerStreamingResiliencyWithMetadata(ctx, 0, 0, []uint32{dresl.OK})
+	if _, err := collect(msgs, errs); err != nil {
+		t.Fatalf("stream ended with error: %v", err)
+	}
+
+	if callMd.Metadata != capture {
+		t.Fatalf("CallMetadata does not record into the capture set on ctx")
+	}
+
+	if got := capture.Trailer.Get("server-trailer"); len(got) != 1 || got[0] != "server-stream" {
+		t.Errorf("captured trailer server-trailer = %v, want [server-stream]", got)
+	}
+}
@@ -0,0 +1,132 @@
+package rpcerr
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error is returned by the adapters when a call fails. It keeps the gRPC
+// status of the failure, so callers can use errors.As to get the code and
+// details, and status.FromError keeps working on it.
+type Error struct {
+	// Op is the adapter method that failed, e.g. "BankAdapter.GetCurrentBalance".
+	Op string
+
+	status *status.Status
+	err    error
+}
+
+// New wraps err as an *Error for op. It returns nil for a nil err, and err
+// unchanged when it already is an *Error. Errors without a gRPC status are
+// mapped with status.FromContextError, so context errors keep their code and
+// anything else becomes codes.Unknown.
+func New(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		st = status.FromContextError(err)
+	}
+
+	return &Error{
+		Op:     op,
+		status: st,
+		err:    err,
+	}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %v | %v", e.Op, e.status.Code(), e.status.Message())
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Code returns the gRPC status code of the failure.
+func (e *Error) Code() codes.Code {
+	return e.status.Code()
+}
+
+// GRPCStatus returns the gRPC status of the failure, including its details.
+func (e *Error) GRPCStatus() *status.Status {
+	return e.status
+}
+
+// Code returns the gRPC status code carried by err, codes.OK for a nil err
+// and codes.Unknown when err carries no status.
+func Code(err error) codes.Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code()
+	}
+
+	return status.Code(err)
+}
+
+// Violation is a single PreconditionFailure or BadRequest violation sent
+// by the server in the error details.
+type Violation struct {
+	// Type is the precondition type, empty for bad request fields.
+	Type string
+	// Subject is the precondition subject or the bad request field.
+	Subject     string
+	Description string
+}
+
+func (v Violation) String() string {
+	if v.Type == "" {
+		return fmt.Sprintf("%v: %v", v.Subject, v.Description)
+	}
+
+	return fmt.Sprintf("%v %v: %v", v.Type, v.Subject, v.Description)
+}
+
+// Violations decodes every PreconditionFailure and BadRequest detail.
+func (e *Error) Violations() []Violation {
+	var violations []Violation
+
+	for _, detail := range e.status.Details() {
+		switch t := detail.(type) {
+		case *errdetails.PreconditionFailure:
+			for _, v := range t.GetViolations() {
+				violations = append(violations, Violation{
+					Type:        v.GetType(),
+					Subject:     v.GetSubject(),
+					Description: v.GetDescription(),
+				})
+			}
+		case *errdetails.BadRequest:
+			for _, v := range t.GetFieldViolations() {
+				violations = append(violations, Violation{
+					Subject:     v.GetField(),
+					Description: v.GetDescription(),
+				})
+			}
+		}
+	}
+
+	return violations
+}
+
+// ErrorInfo returns the first ErrorInfo detail, or nil.
+func (e *Error) ErrorInfo() *errdetails.ErrorInfo {
+	for _, detail := range e.status.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+
+	return nil
+}
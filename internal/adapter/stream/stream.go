@@ -0,0 +1,87 @@
+package stream
+
+import (
+	"context"
+	"io"
+
+	"github.com/fbriansyah/my-grpc-go-client/internal/adapter/rpcerr"
+)
+
+// Receive calls recv in a new goroutine until the stream ends, and hands
+// every message to the caller on the returned channel.
+//
+// The error channel receives at most one error, wrapped with rpcerr.New for
+// op, and is closed right after the message channel, so callers range over
+// the messages and then read the error channel once; a nil read means the
+// stream ended with io.EOF. A caller that stops reading early must cancel
+// ctx to release the goroutine.
+//
+// The streaming methods of the adapters return these channels, or the ones
+// of Fail when the stream cannot be opened. They stop sending at the first
+// failed Send: the stream is broken, and the reason is the error of Recv or
+// CloseAndRecv.
+func Receive[T any](ctx context.Context, op string, recv func() (T, error)) (<-chan T, <-chan error) {
+	msgs := make(chan T)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(msgs)
+
+		for {
+			msg, err := recv()
+
+			if err == io.EOF {
+				return
+			}
+
+			if err != nil {
+				errs <- rpcerr.New(op, err)
+				return
+			}
+
+			select {
+			case msgs <- msg:
+			case <-ctx.Done():
+				errs <- rpcerr.New(op, ctx.Err())
+				return
+			}
+		}
+	}()
+
+	return msgs, errs
+}
+
+// Fail returns closed channels that only carry err wrapped for op, for
+// streams that could not be opened.
+func Fail[T any](op string, err error) (<-chan T, <-chan error) {
+	msgs := make(chan T)
+	errs := make(chan error, 1)
+
+	errs <- rpcerr.New(op, err)
+
+	close(msgs)
+	close(errs)
+
+	return msgs, errs
+}
+
+// Finally returns an error channel that delivers the error from errs only
+// after fn ran, once the stream ended; use it for work that must be done
+// before the caller sees the end of the stream.
+func Finally(errs <-chan error, fn func()) <-chan error {
+	out := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+
+		err, ok := <-errs
+		fn()
+
+		if ok {
+			out <- err
+		}
+	}()
+
+	return out
+}
@@ -1,10 +1,27 @@
 package bank
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRequest is wrapped by every validation error, so callers can
+// tell them apart from RPC failures with errors.Is.
+var ErrInvalidRequest = errors.New("invalid request")
+
 const (
 	TransactionTypeIn  string = "IN"
 	TransactionTypeOut string = "OUT"
 )
 
+const (
+	TransferStatusUnspecified string = "UNSPECIFIED"
+	TransferStatusSuccess     string = "SUCCESS"
+	TransferStatusFailed      string = "FAILED"
+)
+
 type Transaction struct {
 	Amount          float64
 	TransactionType string
@@ -17,3 +34,83 @@ type TransferTransaction struct {
 	Currency          string
 	Amount            float64
 }
+
+type Balance struct {
+	Amount float64
+	// Date is the day the balance was taken, at midnight UTC.
+	Date time.Time
+}
+
+type ExchangeRate struct {
+	FromCurrency string
+	ToCurrency   string
+	Rate         float64
+	// Timestamp is passed through as sent by the server.
+	Timestamp string
+}
+
+type TransactionSummary struct {
+	AccountNumber string
+	SumAmountIn   float64
+	SumAmountOut  float64
+	SumTotal      float64
+	// TransactionDate is the summarized day, at midnight UTC.
+	TransactionDate time.Time
+}
+
+type TransferResult struct {
+	FromAccountNumber string
+	ToAccountNumber   string
+	Currency          string
+	Amount            float64
+	// Status is one of the TransferStatus constants.
+	Status    string
+	Timestamp time.Time
+}
+
+type CreateAccount struct {
+	AccountName          string
+	Currency             string
+	InitialDepositAmount float64
+}
+
+// Validate checks the request before it is sent to the server.
+func (c CreateAccount) Validate() error {
+	var problems []string
+
+	if strings.TrimSpace(c.AccountName) == "" {
+		problems = append(problems, "account name must not be empty")
+	}
+
+	if !isCurrencyCode(c.Currency) {
+		problems = append(problems, fmt.Sprintf("currency %q must be a 3-letter uppercase code", c.Currency))
+	}
+
+	if c.InitialDepositAmount < 0 {
+		problems = append(problems, fmt.Sprintf("initial deposit %v must not be negative", c.InitialDepositAmount))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, strings.Join(problems, ", "))
+	}
+
+	return nil
+}
+
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+type Account struct {
+	AccountUUID string
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// Credentials send a token with every call, as
+// credentials.PerRPCCredentials.
+type Credentials struct {
+	// Header is the metadata key carrying the token.
+	Header string
+	// Prefix is written before the token, e.g. "Bearer ".
+	Prefix string
+	Source TokenSource
+	// AllowInsecure sends the token on connections without TLS, for local
+	// development. Otherwise such calls fail rather than leak the token.
+	AllowInsecure bool
+}
+
+// Bearer sends the tokens of src as "authorization: Bearer <token>".
+func Bearer(src TokenSource) *Credentials {
+	return &Credentials{Header: "authorization", Prefix: "Bearer ", Source: src}
+}
+
+// APIKey sends the keys of src in header, e.g. "x-api-key".
+func APIKey(header string, src TokenSource) *Credentials {
+	return &Credentials{Header: header, Source: src}
+}
+
+func (c *Credentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	if !c.AllowInsecure {
+		ri, _ := credentials.RequestInfoFromContext(ctx)
+		if err := credentials.CheckSecurityLevel(ri.AuthInfo, credentials.PrivacyAndIntegrity); err != nil {
+			return nil, fmt.Errorf("auth: refusing to send %v on a connection without TLS: %w", c.Header, err)
+		}
+	}
+
+	token, err := c.Source.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{c.Header: c.Prefix + token.Value}, nil
+}
+
+func (c *Credentials) RequireTransportSecurity() bool {
+	return !c.AllowInsecure
+}
+
+// PerMethod sends the credentials of the method of every call, as returned
+// by credentials, e.g. Policies.Credentials. A method without credentials
+// sends none.
+func PerMethod(credentials func(method string) credentials.PerRPCCredentials) credentials.PerRPCCredentials {
+	return perMethod(credentials)
+}
+
+type perMethod func(method string) credentials.PerRPCCredentials
+
+func (f perMethod) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	ri, _ := credentials.RequestInfoFromContext(ctx)
+
+	c := f(ri.Method)
+	if c == nil {
+		return nil, nil
+	}
+
+	return c.GetRequestMetadata(ctx, uri...)
+}
+
+// RequireTransportSecurity is false so that methods without credentials can
+// be called without TLS, the credentials of a method check it themselves.
+func (f perMethod) RequireTransportSecurity() bool {
+	return false
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fbriansyah/my-grpc-go-client/internal/fakeserver"
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/bank"
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/hello"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// bankOnly returns c for the bank service only.
+func bankOnly(c *Credentials) func(method string) credentials.PerRPCCredentials {
+	return func(method string) credentials.PerRPCCredentials {
+		if method == "/bank.BankService/GetCurrentBalance" {
+			return c
+		}
+
+		return nil
+	}
+}
+
+func TestPerMethod(t *testing.T) {
+	creds := Bearer(StaticToken("secret"))
+	creds.AllowInsecure = true
+
+	srv, conn := fakeserver.Start(t, grpc.WithPerRPCCredentials(PerMethod(bankOnly(creds))))
+	ctx := context.Background()
+
+	if _, err := bank.NewBankServiceClient(conn).GetCurrentBalance(ctx, &bank.CurrentBalanceRequest{}); err != nil {
+		t.Fatalf("GetCurrentBalance: %v", err)
+	}
+
+	if _, err := hello.NewHelloServiceClient(conn).SayHello(ctx, &hello.HelloRequest{}); err != nil {
+		t.Fatalf("SayHello: %v", err)
+	}
+
+	got := srv.Bank.Calls("GetCurrentBalance")[0].Metadata.Get("authorization")
+	if len(got) != 1 || got[0] != "Bearer secret" {
+		t.Errorf("bank authorization = %v, want [Bearer secret]", got)
+	}
+
+	if got := srv.Hello.Calls("SayHello")[0].Metadata.Get("authorization"); len(got) != 0 {
+		t.Errorf("hello authorization = %v, want none", got)
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	creds := APIKey("x-api-key", StaticToken("k-123"))
+	creds.AllowInsecure = true
+
+	srv, conn := fakeserver.Start(t, grpc.WithPerRPCCredentials(PerMethod(bankOnly(creds))))
+
+	if _, err := bank.NewBankServiceClient(conn).GetCurrentBalance(context.Background(),
+		&bank.CurrentBalanceRequest{}); err != nil {
+		t.Fatalf("GetCurrentBalance: %v", err)
+	}
+
+	if got := srv.Bank.Calls("GetCurrentBalance")[0].Metadata.Get("x-api-key"); len(got) != 1 || got[0] != "k-123" {
+		t.Errorf("x-api-key = %v, want [k-123]", got)
+	}
+}
+
+func TestCredentialsRequireTLS(t *testing.T) {
+	srv, conn := fakeserver.Start(t, grpc.WithPerRPCCredentials(PerMethod(bankOnly(Bearer(StaticToken("secret"))))))
+
+	_, err := bank.NewBankServiceClient(conn).GetCurrentBalance(context.Background(), &bank.CurrentBalanceRequest{})
+	if got := status.Code(err); got != codes.Unauthenticated {
+		t.Fatalf("code = %v, want %v", got, codes.Unauthenticated)
+	}
+
+	if calls := srv.Bank.Calls("GetCurrentBalance"); len(calls) != 0 {
+		t.Errorf("server got %d calls, want the token kept off the insecure connection", len(calls))
+	}
+
+	// methods without credentials still work without TLS
+	if _, err := hello.NewHelloServiceClient(conn).SayHello(context.Background(), &hello.HelloRequest{}); err != nil {
+		t.Errorf("SayHello: %v", err)
+	}
+}
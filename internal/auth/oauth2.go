@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ClientCredentials gets tokens from an OAuth2 token endpoint with the
+// client credentials grant, RFC 6749 section 4.4. Every call to Token asks
+// for a new token, wrap it with Refreshing to reuse them until they expire.
+type ClientCredentials struct {
+	// TokenURL is the token endpoint.
+	TokenURL string
+	ClientID string
+	// ClientSecret returns the secret of the client, read for every
+	// request so that it can be rotated.
+	ClientSecret TokenSource
+	Scopes       []string
+	// HTTPClient sends the requests, http.DefaultClient when nil.
+	HTTPClient *http.Client
+	// MaxAttempts is the number of requests made for a token when the
+	// endpoint cannot be reached, answers 429 or a 5xx status. 3 when 0.
+	MaxAttempts int
+	// Backoff is the wait before the second attempt, doubled before each
+	// following one. 200ms when 0.
+	Backoff time.Duration
+}
+
+// tokenResponse is the answer of a token endpoint, successful or not.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// transientError is a failure worth another attempt.
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+func (c *ClientCredentials) Token(ctx context.Context) (Token, error) {
+	attempts := c.MaxAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+
+	backoff := c.Backoff
+	if backoff <= 0 {
+		backoff = 200 * time.Millisecond
+	}
+
+	for attempt := 1; ; attempt++ {
+		token, err := c.fetch(ctx)
+
+		var transient *transientError
+		if err == nil || !errors.As(err, &transient) || attempt == attempts {
+			return token, err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return Token{}, fmt.Errorf("auth: %w, after %v", ctx.Err(), err)
+		}
+		backoff *= 2
+	}
+}
+
+// fetch makes a single token request.
+func (c *ClientCredentials) fetch(ctx context.Context) (Token, error) {
+	secret, err := c.ClientSecret.Token(ctx)
+	if err != nil {
+		return Token{}, err
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, fmt.Errorf("auth: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(secret.Value))
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	start := time.Now()
+
+	res, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return Token{}, fmt.Errorf("auth: token request: %w", err)
+		}
+
+		return Token{}, &transientError{fmt.Errorf("auth: token request: %w", err)}
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return Token{}, &transientError{fmt.Errorf("auth: token response: %w", err)}
+	}
+
+	var tr tokenResponse
+	jsonErr := json.Unmarshal(body, &tr)
+
+	if res.StatusCode != http.StatusOK {
+		err := fmt.Errorf("auth: token endpoint answered %v", res.Status)
+		if tr.Error != "" {
+			err = fmt.Errorf("auth: token endpoint answered %v: %v %v", res.Status, tr.Error, tr.ErrorDescription)
+		}
+
+		if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500 {
+			return Token{}, &transientError{err}
+		}
+
+		return Token{}, err
+	}
+
+	if jsonErr != nil {
+		return Token{}, fmt.Errorf("auth: token response: %w", jsonErr)
+	}
+
+	if tr.AccessToken == "" {
+		return Token{}, errors.New("auth: token response without access_token")
+	}
+
+	if tr.TokenType != "" && !strings.EqualFold(tr.TokenType, "bearer") {
+		return Token{}, fmt.Errorf("auth: unsupported token type %q", tr.TokenType)
+	}
+
+	token := Token{Value: tr.AccessToken}
+	if tr.ExpiresIn > 0 {
+		// counted from the request, the token may have been issued any
+		// time after it was sent
+		token.Expiry = start.Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fbriansyah/my-grpc-go-client/internal/fakeserver"
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/bank"
+	"google.golang.org/grpc"
+)
+
+func newClientCredentials(tokens *fakeserver.TokenServer) *ClientCredentials {
+	return &ClientCredentials{
+		TokenURL:     tokens.URL,
+		ClientID:     tokens.ClientID,
+		ClientSecret: StaticToken(tokens.ClientSecret),
+		Scopes:       []string{"bank.read", "bank.write"},
+		Backoff:      time.Millisecond,
+	}
+}
+
+func TestClientCredentialsCached(t *testing.T) {
+	tokens := fakeserver.StartTokenServer(t, "cli", "s3cret")
+	src := Refreshing(newClientCredentials(tokens), time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		token, err := src.Token(ctx)
+		if err != nil || token.Value != "token-1" {
+			t.Fatalf("Token = %v, %v, want token-1", token, err)
+		}
+
+		if d := time.Until(token.Expiry); d < 59*time.Minute || d > time.Hour {
+			t.Errorf("token expires in %v, want an hour", d)
+		}
+	}
+
+	requests := tokens.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("got %d token requests, want 1", len(requests))
+	}
+
+	if got := requests[0].Get("scope"); got != "bank.read bank.write" {
+		t.Errorf("scope = %q, want the scopes space separated", got)
+	}
+}
+
+func TestClientCredentialsRefreshedAheadOfExpiry(t *testing.T) {
+	tokens := fakeserver.StartTokenServer(t, "cli", "s3cret")
+	tokens.On(fakeserver.TokenBehavior{ExpiresIn: 90 * time.Second})
+
+	// refresh_early longer than the token lifetime is clamped to half of it
+	clock := &fakeClock{now: time.Now()}
+	src := refreshingWithClock(newClientCredentials(tokens), 2*time.Minute, clock)
+	ctx := context.Background()
+
+	for _, d := range []time.Duration{0, 10 * time.Second, 30 * time.Second} {
+		clock.Advance(d)
+		if token, err := src.Token(ctx); err != nil || token.Value != "token-1" {
+			t.Fatalf("Token = %v, %v, want the cached token-1", token, err)
+		}
+	}
+
+	clock.Advance(10 * time.Second)
+	if token, err := src.Token(ctx); err != nil || token.Value != "token-2" {
+		t.Fatalf("Token = %v, %v, want the refreshed token-2", token, err)
+	}
+
+	// the endpoint failing keeps the token while it is valid
+	tokens.On(fakeserver.TokenBehavior{FailWith: []int{500, 500, 500}})
+	clock.Advance(30 * time.Second)
+	if token, err := src.Token(ctx); err != nil || token.Value != "token-2" {
+		t.Errorf("Token = %v, %v, want token-2 while the endpoint fails", token, err)
+	}
+
+	if got := len(tokens.Requests()); got != 5 {
+		t.Errorf("got %d token requests, want 2 grants and 3 failed refresh attempts", got)
+	}
+}
+
+func TestClientCredentialsRetriesTransientFailures(t *testing.T) {
+	tokens := fakeserver.StartTokenServer(t, "cli", "s3cret")
+	tokens.On(fakeserver.TokenBehavior{FailWith: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}})
+
+	token, err := newClientCredentials(tokens).Token(context.Background())
+	if err != nil || token.Value != "token-1" {
+		t.Fatalf("Token = %v, %v, want token-1", token, err)
+	}
+
+	if got := len(tokens.Requests()); got != 3 {
+		t.Errorf("got %d token requests, want 3", got)
+	}
+
+	tokens.On(fakeserver.TokenBehavior{FailWith: []int{500, 500, 500, 500}})
+	if _, err := newClientCredentials(tokens).Token(context.Background()); err == nil {
+		t.Error("Token succeeded once the attempts ran out")
+	}
+
+	if got := len(tokens.Requests()); got != 6 {
+		t.Errorf("got %d token requests, want 3 more", got)
+	}
+}
+
+func TestClientCredentialsInvalidClientNotRetried(t *testing.T) {
+	tokens := fakeserver.StartTokenServer(t, "cli", "s3cret")
+
+	cc := newClientCredentials(tokens)
+	cc.ClientSecret = StaticToken("wrong")
+
+	if _, err := cc.Token(context.Background()); err == nil {
+		t.Fatal("Token with a wrong secret succeeded")
+	}
+
+	if got := len(tokens.Requests()); got != 1 {
+		t.Errorf("got %d token requests, want 1", got)
+	}
+}
+
+func TestClientCredentialsEncoded(t *testing.T) {
+	tests := []struct {
+		name, id, secret string
+	}{
+		{"plain", "cli", "s3cret"},
+		{"reserved characters in the secret", "cli", "s3cr+t/="},
+		{"space and colon", "my client:1", "s3 cr:et"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := fakeserver.StartTokenServer(t, tt.id, tt.secret)
+
+			token, err := newClientCredentials(tokens).Token(context.Background())
+			if err != nil || token.Value != "token-1" {
+				t.Errorf("Token = %v, %v, want token-1", token, err)
+			}
+		})
+	}
+}
+
+func TestClientCredentialsPerRPC(t *testing.T) {
+	tokens := fakeserver.StartTokenServer(t, "cli", "s3cret")
+
+	creds := Bearer(Refreshing(newClientCredentials(tokens), time.Minute))
+	creds.AllowInsecure = true
+
+	srv, conn := fakeserver.Start(t, grpc.WithPerRPCCredentials(PerMethod(bankOnly(creds))))
+	client := bank.NewBankServiceClient(conn)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetCurrentBalance(context.Background(), &bank.CurrentBalanceRequest{}); err != nil {
+			t.Fatalf("GetCurrentBalance: %v", err)
+		}
+	}
+
+	for _, call := range srv.Bank.Calls("GetCurrentBalance") {
+		if got := call.Metadata.Get("authorization"); len(got) != 1 || got[0] != "Bearer token-1" {
+			t.Errorf("authorization = %v, want [Bearer token-1]", got)
+		}
+	}
+
+	if got := len(tokens.Requests()); got != 1 {
+		t.Errorf("got %d token requests, want the token reused", got)
+	}
+}
@@ -0,0 +1,136 @@
+// Package auth provides the per call credentials of the client: bearer
+// tokens and API keys, read from the config, a file or the environment or
+// obtained with OAuth2 client credentials, and cached until they expire.
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token is an access token or API key.
+type Token struct {
+	Value string
+	// Expiry is when the token stops being valid, the zero time for never.
+	Expiry time.Time
+}
+
+// TokenSource returns the token of a call.
+type TokenSource interface {
+	Token(ctx context.Context) (Token, error)
+}
+
+// TokenSourceFunc is a function used as a TokenSource.
+type TokenSourceFunc func(ctx context.Context) (Token, error)
+
+func (f TokenSourceFunc) Token(ctx context.Context) (Token, error) {
+	return f(ctx)
+}
+
+// StaticToken always returns value.
+func StaticToken(value string) TokenSource {
+	return TokenSourceFunc(func(context.Context) (Token, error) {
+		return Token{Value: value}, nil
+	})
+}
+
+// EnvToken returns the value of the environment variable name, read on
+// every call.
+func EnvToken(name string) TokenSource {
+	return TokenSourceFunc(func(context.Context) (Token, error) {
+		value := os.Getenv(name)
+		if value == "" {
+			return Token{}, fmt.Errorf("auth: environment variable %v is empty", name)
+		}
+
+		return Token{Value: value}, nil
+	})
+}
+
+// FileToken returns the content of the file at path, without surrounding
+// white space. The token expires ttl after the file is read, so Refreshing
+// reads it again then; a ttl of 0 never expires.
+func FileToken(path string, ttl time.Duration) TokenSource {
+	return TokenSourceFunc(func(context.Context) (Token, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Token{}, fmt.Errorf("auth: %w", err)
+		}
+
+		value := strings.TrimSpace(string(data))
+		if value == "" {
+			return Token{}, fmt.Errorf("auth: token file %v is empty", path)
+		}
+
+		token := Token{Value: value}
+		if ttl > 0 {
+			token.Expiry = time.Now().Add(ttl)
+		}
+
+		return token, nil
+	})
+}
+
+// refreshingSource caches the token of a source.
+type refreshingSource struct {
+	src   TokenSource
+	early time.Duration
+	// now is time.Now, replaced by tests.
+	now func() time.Time
+
+	mu    sync.Mutex
+	token Token
+	// refreshAt is when a new token is got, the zero time for never.
+	refreshAt time.Time
+}
+
+// Refreshing caches the tokens of src, getting a new one once the cached
+// one expires within early, or within half its lifetime when that is
+// shorter so that short lived tokens are not fetched for every call. When
+// the refresh fails, the cached token is used as long as it is valid.
+// Concurrent calls wait for a single refresh.
+func Refreshing(src TokenSource, early time.Duration) TokenSource {
+	return &refreshingSource{src: src, early: early, now: time.Now}
+}
+
+func (s *refreshingSource) Token(ctx context.Context) (Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	if s.token.Value != "" && (s.refreshAt.IsZero() || now.Before(s.refreshAt)) {
+		return s.token, nil
+	}
+
+	token, err := s.src.Token(ctx)
+	if err != nil {
+		if s.token.Value != "" && (s.token.Expiry.IsZero() || now.Before(s.token.Expiry)) {
+			return s.token, nil
+		}
+
+		return Token{}, err
+	}
+
+	s.token = token
+	s.refreshAt = time.Time{}
+
+	if !token.Expiry.IsZero() {
+		early := s.early
+		if half := token.Expiry.Sub(now) / 2; early > half {
+			early = half
+		}
+
+		if early < 0 {
+			early = 0
+		}
+
+		s.refreshAt = token.Expiry.Add(-early)
+	}
+
+	return token, nil
+}
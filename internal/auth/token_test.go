@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// countingSource returns the tokens of next, counting the calls.
+type countingSource struct {
+	calls int
+	next  func(n int) (Token, error)
+}
+
+func (s *countingSource) Token(context.Context) (Token, error) {
+	s.calls++
+	return s.next(s.calls)
+}
+
+// fakeClock is a time.Now that only moves when advanced.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// refreshingWithClock is Refreshing with its time read from clock.
+func refreshingWithClock(src TokenSource, early time.Duration, clock *fakeClock) TokenSource {
+	s := Refreshing(src, early).(*refreshingSource)
+	s.now = clock.Now
+
+	return s
+}
+
+// tokenFor returns a source of the tokens "a", "b" and so on, each valid
+// for lifetime from the time of clock.
+func tokenFor(clock *fakeClock, lifetime time.Duration) *countingSource {
+	return &countingSource{next: func(n int) (Token, error) {
+		return Token{Value: string(rune('a' + n - 1)), Expiry: clock.Now().Add(lifetime)}, nil
+	}}
+}
+
+func TestRefreshingCachesUntilExpiry(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	src := tokenFor(clock, time.Hour)
+	tokens := refreshingWithClock(src, time.Minute, clock)
+	ctx := context.Background()
+
+	for _, d := range []time.Duration{0, 30 * time.Minute, 28*time.Minute + 59*time.Second} {
+		clock.Advance(d)
+		if token, err := tokens.Token(ctx); err != nil || token.Value != "a" {
+			t.Fatalf("Token = %v, %v, want the cached a", token, err)
+		}
+	}
+
+	// expiring within early is refreshed ahead of time
+	clock.Advance(time.Second)
+	if token, err := tokens.Token(ctx); err != nil || token.Value != "b" {
+		t.Fatalf("Token = %v, %v, want b a minute before a expires", token, err)
+	}
+
+	if src.calls != 2 {
+		t.Errorf("source called %d times, want 2", src.calls)
+	}
+}
+
+func TestRefreshingClampsEarlyToHalfTheLifetime(t *testing.T) {
+	tests := []struct {
+		name     string
+		early    time.Duration
+		lifetime time.Duration
+		// refreshAfter is how long a token is used
+		refreshAfter time.Duration
+	}{
+		{"early shorter than half", 10 * time.Second, time.Minute, 50 * time.Second},
+		{"early longer than half", 2 * time.Minute, 90 * time.Second, 45 * time.Second},
+		{"early longer than the lifetime", time.Hour, 30 * time.Second, 15 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Now()}
+			src := tokenFor(clock, tt.lifetime)
+			tokens := refreshingWithClock(src, tt.early, clock)
+			ctx := context.Background()
+
+			tokens.Token(ctx)
+			clock.Advance(tt.refreshAfter - time.Second)
+			tokens.Token(ctx)
+
+			if src.calls != 1 {
+				t.Errorf("source called %d times before %v, want the token cached", src.calls, tt.refreshAfter)
+			}
+
+			clock.Advance(time.Second)
+			tokens.Token(ctx)
+
+			if src.calls != 2 {
+				t.Errorf("source called %d times after %v, want a refresh", src.calls, tt.refreshAfter)
+			}
+		})
+	}
+}
+
+func TestRefreshingExpiredToken(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	src := tokenFor(clock, -time.Second)
+	tokens := refreshingWithClock(src, time.Minute, clock)
+
+	tokens.Token(context.Background())
+	tokens.Token(context.Background())
+
+	if src.calls != 2 {
+		t.Errorf("source called %d times, want an expired token never cached", src.calls)
+	}
+}
+
+func TestRefreshingWithoutExpiry(t *testing.T) {
+	src := &countingSource{next: func(n int) (Token, error) { return Token{Value: "a"}, nil }}
+	tokens := Refreshing(src, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		tokens.Token(context.Background())
+	}
+
+	if src.calls != 1 {
+		t.Errorf("source called %d times, want a token without expiry kept", src.calls)
+	}
+}
+
+func TestRefreshingKeepsValidTokenOnFailure(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	src := &countingSource{next: func(n int) (Token, error) {
+		if n > 1 {
+			return Token{}, errors.New("token endpoint down")
+		}
+
+		return Token{Value: "a", Expiry: clock.Now().Add(time.Minute)}, nil
+	}}
+	tokens := refreshingWithClock(src, 20*time.Second, clock)
+	ctx := context.Background()
+
+	tokens.Token(ctx)
+	clock.Advance(50 * time.Second)
+	if token, err := tokens.Token(ctx); err != nil || token.Value != "a" {
+		t.Fatalf("Token = %v, %v, want the still valid a", token, err)
+	}
+
+	if src.calls != 2 {
+		t.Errorf("source called %d times, want a refresh attempt", src.calls)
+	}
+
+	clock.Advance(10 * time.Second)
+	if _, err := tokens.Token(ctx); err == nil {
+		t.Error("Token returned an expired token")
+	}
+}
+
+func TestFileToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := FileToken(path, time.Minute).Token(context.Background())
+	if err != nil || token.Value != "secret" {
+		t.Fatalf("Token = %v, %v, want secret", token, err)
+	}
+
+	if d := time.Until(token.Expiry); d <= 0 || d > time.Minute {
+		t.Errorf("token expires in %v, want a minute", d)
+	}
+
+	if _, err := FileToken(filepath.Join(t.TempDir(), "missing"), 0).Token(context.Background()); err == nil {
+		t.Error("Token of a missing file succeeded")
+	}
+}
+
+func TestEnvToken(t *testing.T) {
+	t.Setenv("AUTH_TEST_TOKEN", "secret")
+
+	if token, err := EnvToken("AUTH_TEST_TOKEN").Token(context.Background()); err != nil || token.Value != "secret" {
+		t.Errorf("Token = %v, %v, want secret", token, err)
+	}
+
+	if _, err := EnvToken("AUTH_TEST_UNSET").Token(context.Background()); err == nil {
+		t.Error("Token of an unset variable succeeded")
+	}
+}
@@ -0,0 +1,110 @@
+// Package callmeta records the metadata exchanged on a call. It is shared by
+// the adapters, which read the response header and trailer, and the
+// interceptors, which add the request metadata.
+package callmeta
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestUUIDKey is the key of the UUID identifying a call, sent by the
+// request UUID interceptor.
+const RequestUUIDKey = "grpc-request-uuid"
+
+// Metadata is the metadata exchanged on a call.
+//
+// For calls returning channels, the response metadata is filled in when the
+// stream ends and must only be read once the error channel is closed.
+type Metadata struct {
+	// Request is the metadata sent with the call, recorded by the
+	// interceptor adding it with SetRequest.
+	Request metadata.MD
+	Header  metadata.MD
+	Trailer metadata.MD
+}
+
+type captureKey struct{}
+
+// WithCapture returns a copy of ctx that asks the adapters and interceptors
+// to record the metadata of the calls made with it into the returned
+// Metadata. A capture records one call at a time, the last call made wins.
+func WithCapture(ctx context.Context) (context.Context, *Metadata) {
+	md := &Metadata{}
+
+	return context.WithValue(ctx, captureKey{}, md), md
+}
+
+// FromContext returns the capture set by WithCapture, or nil. Every method
+// of Metadata is a no-op on nil, so adapters use the result unchecked.
+func FromContext(ctx context.Context) *Metadata {
+	md, _ := ctx.Value(captureKey{}).(*Metadata)
+
+	return md
+}
+
+// SetRequest records md as the metadata sent with the call.
+func (m *Metadata) SetRequest(md metadata.MD) {
+	if m == nil {
+		return
+	}
+
+	m.Request = md
+}
+
+// RequestUUID returns the UUID sent with the call, or "" when none was.
+func (m *Metadata) RequestUUID() string {
+	if m == nil {
+		return ""
+	}
+
+	if values := m.Request.Get(RequestUUIDKey); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// CallOptions returns the options recording the header and trailer of a
+// unary call into m.
+func (m *Metadata) CallOptions() []grpc.CallOption {
+	if m == nil {
+		return nil
+	}
+
+	return []grpc.CallOption{grpc.Header(&m.Header), grpc.Trailer(&m.Trailer)}
+}
+
+// FromStream records the header and trailer of s into m. It must only be
+// called once the stream ended, e.g. after CloseAndRecv.
+func (m *Metadata) FromStream(s grpc.ClientStream) {
+	if m == nil {
+		return
+	}
+
+	if header, err := s.Header(); err == nil {
+		m.Header = header
+	}
+
+	m.Trailer = s.Trailer()
+}
+
+// Recv wraps recv, the Recv method of s, to record the header and trailer
+// of s into m once the stream ended.
+func Recv[T any](m *Metadata, s grpc.ClientStream, recv func() (T, error)) func() (T, error) {
+	if m == nil {
+		return recv
+	}
+
+	return func() (T, error) {
+		msg, err := recv()
+
+		if err != nil {
+			m.FromStream(s)
+		}
+
+		return msg, err
+	}
+}
@@ -0,0 +1,604 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"gopkg.in/yaml.v3"
+)
+
+// Duration is a time.Duration that is written as "5s" or "300ms" in config
+// files.
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d *Duration) set(value string) error {
+	v, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"5s\": %w", err)
+	}
+
+	return d.set(s)
+}
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	return d.set(node.Value)
+}
+
+type Config struct {
+	// Target is the address passed to grpc.Dial.
+	Target         string                   `json:"target" yaml:"target"`
+	TLS            TLSConfig                `json:"tls" yaml:"tls"`
+	Interceptor    InterceptorConfig        `json:"interceptor" yaml:"interceptor"`
+	CircuitBreaker CircuitBreakerConfig     `json:"circuit_breaker" yaml:"circuit_breaker"`
+	Retry          RetryPolicyConfig        `json:"retry" yaml:"retry"`
+	Policies       map[string]PolicyConfig  `json:"policies,omitempty" yaml:"policies,omitempty"`
+	Logging        LoggingConfig            `json:"logging" yaml:"logging"`
+	Metadata       MetadataConfig           `json:"metadata" yaml:"metadata"`
+	Services       map[string]ServiceConfig `json:"services" yaml:"services"`
+	// Credentials are the per call credentials, by the name policies use.
+	Credentials map[string]CredentialsConfig `json:"credentials,omitempty" yaml:"credentials,omitempty"`
+}
+
+type TLSConfig struct {
+	// Insecure dials without transport security, for local development.
+	Insecure bool `json:"insecure" yaml:"insecure"`
+	// CAFile is a PEM bundle of the CAs trusted to sign the server
+	// certificate, the system pool is used when empty.
+	CAFile string `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`
+	// CertFile and KeyFile are the PEM client certificate and key sent for
+	// mutual TLS.
+	CertFile string `json:"cert_file,omitempty" yaml:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty" yaml:"key_file,omitempty"`
+	// ServerName is checked against the server certificate instead of the
+	// host of the target.
+	ServerName string `json:"server_name,omitempty" yaml:"server_name,omitempty"`
+	// ReloadInterval is how often the files are checked for changes, 0
+	// never reloads them.
+	ReloadInterval Duration `json:"reload_interval,omitempty" yaml:"reload_interval,omitempty"`
+}
+
+// CredentialsNone is the credentials name of a policy sending none, to
+// exempt a method of a service that has credentials.
+const CredentialsNone = "none"
+
+type CredentialsConfig struct {
+	// Type is bearer, sent as "authorization: Bearer <token>", api_key,
+	// sent in Header, or oauth2, a bearer token obtained with the client
+	// credentials of OAuth2.
+	Type string `json:"type" yaml:"type"`
+	// Header carries an api_key, x-api-key by default.
+	Header string `json:"header,omitempty" yaml:"header,omitempty"`
+	// Exactly one of Token, File and Env holds the token or key, or the
+	// client secret for oauth2. File and Env keep the secret out of the
+	// config file.
+	Token string `json:"token,omitempty" yaml:"token,omitempty"`
+	File  string `json:"file,omitempty" yaml:"file,omitempty"`
+	Env   string `json:"env,omitempty" yaml:"env,omitempty"`
+	// RefreshInterval is how long a token read from File is used before
+	// the file is read again, 0 reads it for every call.
+	RefreshInterval Duration `json:"refresh_interval,omitempty" yaml:"refresh_interval,omitempty"`
+	// OAuth2 is the client of the oauth2 type.
+	OAuth2 OAuth2Config `json:"oauth2,omitempty" yaml:"oauth2,omitempty"`
+}
+
+type OAuth2Config struct {
+	// TokenURL is the token endpoint of the authorization server.
+	TokenURL string   `json:"token_url" yaml:"token_url"`
+	ClientID string   `json:"client_id" yaml:"client_id"`
+	Scopes   []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	// RefreshEarly gets a new token this long before the current one
+	// expires, DefaultRefreshEarly when 0. Tokens are used for at least
+	// half their lifetime whatever its value.
+	RefreshEarly Duration `json:"refresh_early,omitempty" yaml:"refresh_early,omitempty"`
+}
+
+// DefaultRefreshEarly is the RefreshEarly of the oauth2 credentials that do
+// not set it.
+const DefaultRefreshEarly = Duration(30 * time.Second)
+
+// EarlyRefresh returns RefreshEarly, or DefaultRefreshEarly when unset.
+func (c OAuth2Config) EarlyRefresh() time.Duration {
+	if c.RefreshEarly == 0 {
+		return time.Duration(DefaultRefreshEarly)
+	}
+
+	return time.Duration(c.RefreshEarly)
+}
+
+// MetadataProviders are the names of the dynamic metadata that can be sent
+// with every call.
+var MetadataProviders = []string{"request-uuid", "client-time", "client-os", "client-version"}
+
+type MetadataConfig struct {
+	// Static is sent with every call, the metadata of a policy wins over it.
+	Static map[string]string `json:"static,omitempty" yaml:"static,omitempty"`
+	// Dynamic names the metadata computed for every call, out of
+	// MetadataProviders.
+	Dynamic []string `json:"dynamic" yaml:"dynamic"`
+}
+
+type LoggingConfig struct {
+	// Payloads logs the messages of every call, with the fields of Redact
+	// and of the default bank rules masked.
+	Payloads bool `json:"payloads" yaml:"payloads"`
+	// Redact lists more fields to mask by full message name, e.g.
+	// "bank.TransferRequest": ["amount"].
+	Redact map[string][]string `json:"redact,omitempty" yaml:"redact,omitempty"`
+}
+
+type TimeoutConfig struct {
+	// UnaryTimeout bounds every unary call, retries included.
+	UnaryTimeout Duration `json:"unary_timeout,omitempty" yaml:"unary_timeout,omitempty"`
+	// StreamTimeout caps the whole lifetime of a stream, retries included.
+	StreamTimeout Duration `json:"stream_timeout,omitempty" yaml:"stream_timeout,omitempty"`
+	// StreamIdleTimeout ends a stream once no message was sent or received
+	// for this long, 0 means no limit.
+	StreamIdleTimeout Duration `json:"stream_idle_timeout,omitempty" yaml:"stream_idle_timeout,omitempty"`
+}
+
+func (t TimeoutConfig) merge(o TimeoutConfig) TimeoutConfig {
+	if o.UnaryTimeout != 0 {
+		t.UnaryTimeout = o.UnaryTimeout
+	}
+
+	if o.StreamTimeout != 0 {
+		t.StreamTimeout = o.StreamTimeout
+	}
+
+	if o.StreamIdleTimeout != 0 {
+		t.StreamIdleTimeout = o.StreamIdleTimeout
+	}
+
+	return t
+}
+
+type InterceptorConfig struct {
+	TimeoutConfig `yaml:",inline"`
+}
+
+type BreakerPolicyConfig struct {
+	// MinRequests is the number of requests seen before the breaker may trip.
+	MinRequests uint32 `json:"min_requests,omitempty" yaml:"min_requests,omitempty"`
+	// FailureRatio trips the breaker once failures/requests reaches it.
+	FailureRatio float64 `json:"failure_ratio,omitempty" yaml:"failure_ratio,omitempty"`
+	// Timeout is how long the breaker stays open before going half-open.
+	Timeout Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// MaxRequests is the number of requests let through while half-open.
+	MaxRequests uint32 `json:"max_requests,omitempty" yaml:"max_requests,omitempty"`
+	// Interval clears the counts while closed, 0 never clears them.
+	Interval Duration `json:"interval,omitempty" yaml:"interval,omitempty"`
+	// FailureCodes are the names of the status codes counted as failures,
+	// every error counts when empty.
+	FailureCodes []string `json:"failure_codes,omitempty" yaml:"failure_codes,omitempty"`
+}
+
+// FailureStatusCodes parses FailureCodes.
+func (p BreakerPolicyConfig) FailureStatusCodes() ([]codes.Code, error) {
+	return parseCodes(p.FailureCodes)
+}
+
+func (p BreakerPolicyConfig) merge(o BreakerPolicyConfig) BreakerPolicyConfig {
+	if o.MinRequests != 0 {
+		p.MinRequests = o.MinRequests
+	}
+
+	if o.FailureRatio != 0 {
+		p.FailureRatio = o.FailureRatio
+	}
+
+	if o.Timeout != 0 {
+		p.Timeout = o.Timeout
+	}
+
+	if o.MaxRequests != 0 {
+		p.MaxRequests = o.MaxRequests
+	}
+
+	if o.Interval != 0 {
+		p.Interval = o.Interval
+	}
+
+	if o.FailureCodes != nil {
+		p.FailureCodes = o.FailureCodes
+	}
+
+	return p
+}
+
+type CircuitBreakerConfig struct {
+	// Scope is "method" for a breaker per full method name, or "target"
+	// for a single breaker for the server.
+	Scope               string `json:"scope" yaml:"scope"`
+	BreakerPolicyConfig `yaml:",inline"`
+}
+
+type RetryPolicyConfig struct {
+	// MaxAttempts counts the first attempt, 1 disables retries.
+	MaxAttempts int `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty"`
+	// Codes are the names of the retried status codes, e.g. UNAVAILABLE.
+	Codes          []string `json:"codes,omitempty" yaml:"codes,omitempty"`
+	InitialBackoff Duration `json:"initial_backoff,omitempty" yaml:"initial_backoff,omitempty"`
+	MaxBackoff     Duration `json:"max_backoff,omitempty" yaml:"max_backoff,omitempty"`
+	Multiplier     float64  `json:"multiplier,omitempty" yaml:"multiplier,omitempty"`
+	// Jitter spreads every backoff by up to this fraction either way.
+	Jitter float64 `json:"jitter,omitempty" yaml:"jitter,omitempty"`
+}
+
+// StatusCodes parses Codes.
+func (p RetryPolicyConfig) StatusCodes() ([]codes.Code, error) {
+	return parseCodes(p.Codes)
+}
+
+func (p RetryPolicyConfig) merge(o RetryPolicyConfig) RetryPolicyConfig {
+	if o.MaxAttempts != 0 {
+		p.MaxAttempts = o.MaxAttempts
+	}
+
+	if o.Codes != nil {
+		p.Codes = o.Codes
+	}
+
+	if o.InitialBackoff != 0 {
+		p.InitialBackoff = o.InitialBackoff
+	}
+
+	if o.MaxBackoff != 0 {
+		p.MaxBackoff = o.MaxBackoff
+	}
+
+	if o.Multiplier != 0 {
+		p.Multiplier = o.Multiplier
+	}
+
+	if o.Jitter != 0 {
+		p.Jitter = o.Jitter
+	}
+
+	return p
+}
+
+// parseCodes parses status code names like UNAVAILABLE, in any case.
+func parseCodes(names []string) ([]codes.Code, error) {
+	var list []codes.Code
+
+	for _, name := range names {
+		var c codes.Code
+		if err := c.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(name)))); err != nil {
+			return nil, fmt.Errorf("unknown status code %q", name)
+		}
+
+		list = append(list, c)
+	}
+
+	return list, nil
+}
+
+// PolicyConfig overrides the settings of the calls of a method or service,
+// fields left unset are inherited.
+type PolicyConfig struct {
+	TimeoutConfig  `yaml:",inline"`
+	Retry          RetryPolicyConfig   `json:"retry,omitempty" yaml:"retry,omitempty"`
+	CircuitBreaker BreakerPolicyConfig `json:"circuit_breaker,omitempty" yaml:"circuit_breaker,omitempty"`
+	// Metadata is sent with every call, on top of the metadata of the
+	// service for a method.
+	Metadata map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	// Credentials names the entry of the credentials section sent with
+	// every call, or CredentialsNone.
+	Credentials string `json:"credentials,omitempty" yaml:"credentials,omitempty"`
+}
+
+func (p PolicyConfig) merge(o PolicyConfig) PolicyConfig {
+	metadata := map[string]string{}
+	for k, v := range p.Metadata {
+		metadata[k] = v
+	}
+
+	for k, v := range o.Metadata {
+		metadata[k] = v
+	}
+
+	credentials := p.Credentials
+	if o.Credentials != "" {
+		credentials = o.Credentials
+	}
+
+	return PolicyConfig{
+		TimeoutConfig:  p.TimeoutConfig.merge(o.TimeoutConfig),
+		Retry:          p.Retry.merge(o.Retry),
+		CircuitBreaker: p.CircuitBreaker.merge(o.CircuitBreaker),
+		Metadata:       metadata,
+		Credentials:    credentials,
+	}
+}
+
+// servicePattern returns the key of the policy of the service of method,
+// e.g. "/bank.BankService/*" for "/bank.BankService/GetCurrentBalance".
+func servicePattern(method string) string {
+	return method[:strings.LastIndex(method, "/")+1] + "*"
+}
+
+// DefaultPolicy returns the settings of the methods without a policy: the
+// interceptor, retry and circuit_breaker sections.
+func (c *Config) DefaultPolicy() PolicyConfig {
+	return PolicyConfig{
+		TimeoutConfig:  c.Interceptor.TimeoutConfig,
+		Retry:          c.Retry,
+		CircuitBreaker: c.CircuitBreaker.BreakerPolicyConfig,
+	}
+}
+
+// Policy returns the settings of a full method name or service wildcard:
+// the default policy, overridden by the policy of the service and then by
+// the policy of the method.
+func (c *Config) Policy(method string) PolicyConfig {
+	p := c.DefaultPolicy()
+
+	if service := servicePattern(method); service != method {
+		p = p.merge(c.Policies[service])
+	}
+
+	return p.merge(c.Policies[method])
+}
+
+type ServiceConfig struct {
+	// Timeout bounds every command run against the service, 0 means none.
+	Timeout Duration `json:"timeout" yaml:"timeout"`
+}
+
+// Default returns the settings used when nothing else is configured.
+func Default() *Config {
+	return &Config{
+		Target: "localhost:9090",
+		Interceptor: InterceptorConfig{
+			TimeoutConfig: TimeoutConfig{
+				UnaryTimeout:  Duration(5 * time.Second),
+				StreamTimeout: Duration(20 * time.Second),
+			},
+		},
+		CircuitBreaker: CircuitBreakerConfig{
+			Scope: "method",
+			BreakerPolicyConfig: BreakerPolicyConfig{
+				MinRequests:  3,
+				FailureRatio: 0.6,
+				Timeout:      Duration(4 * time.Second),
+				MaxRequests:  3,
+			},
+		},
+		Retry: RetryPolicyConfig{
+			MaxAttempts:    3,
+			Codes:          []string{"UNAVAILABLE"},
+			InitialBackoff: Duration(200 * time.Millisecond),
+			MaxBackoff:     Duration(2 * time.Second),
+			Multiplier:     2,
+			Jitter:         0.2,
+		},
+		Policies: map[string]PolicyConfig{
+			// not idempotent, a retry after a lost response would open a
+			// second account
+			"/bank.BankService/CreateAccount": {Retry: RetryPolicyConfig{MaxAttempts: 1}},
+		},
+		Metadata: MetadataConfig{
+			Dynamic: slices.Clone(MetadataProviders),
+		},
+		Services: map[string]ServiceConfig{},
+	}
+}
+
+// ServiceTimeout returns the configured timeout for service, or 0.
+func (c *Config) ServiceTimeout(service string) time.Duration {
+	return time.Duration(c.Services[service].Timeout)
+}
+
+// FieldError reports an invalid configuration value.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("config: %v %v", e.Field, e.Message)
+}
+
+// Validate returns every invalid field joined in a single error.
+func (c *Config) Validate() error {
+	var errs []error
+
+	invalid := func(field, format string, args ...interface{}) {
+		errs = append(errs, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if c.Target == "" {
+		invalid("target", "must not be empty")
+	}
+
+	if t := c.TLS; t.Insecure && (t.CAFile != "" || t.CertFile != "" || t.KeyFile != "" || t.ServerName != "") {
+		invalid("tls.insecure", "must not be set with ca_file, cert_file, key_file or server_name")
+	}
+
+	if t := c.TLS; (t.CertFile == "") != (t.KeyFile == "") {
+		invalid("tls.cert_file", "must be set with tls.key_file for mutual TLS")
+	}
+
+	if c.TLS.ReloadInterval < 0 {
+		invalid("tls.reload_interval", "must not be negative, got %v", c.TLS.ReloadInterval)
+	}
+
+	validateTimeouts("interceptor.", c.Interceptor.TimeoutConfig, invalid)
+	validateBreakerPolicy("circuit_breaker.", c.CircuitBreaker.BreakerPolicyConfig, invalid)
+	validateRetryPolicy("retry", c.Retry, invalid)
+
+	if cb := c.CircuitBreaker; cb.Scope != "method" && cb.Scope != "target" {
+		invalid("circuit_breaker.scope", "must be method or target, got %q", cb.Scope)
+	}
+
+	for pattern := range c.Policies {
+		field := "policies." + pattern
+
+		if !validPattern(pattern) {
+			invalid(field, "must be a full method name like /bank.BankService/GetCurrentBalance or a service like /bank.BankService/*")
+			continue
+		}
+
+		p := c.Policy(pattern)
+		validateTimeouts(field+".", p.TimeoutConfig, invalid)
+		validateBreakerPolicy(field+".circuit_breaker.", p.CircuitBreaker, invalid)
+		validateRetryPolicy(field+".retry", p.Retry, invalid)
+
+		if _, ok := c.Credentials[p.Credentials]; !ok && p.Credentials != "" && p.Credentials != CredentialsNone {
+			invalid(field+".credentials", "unknown credentials %q", p.Credentials)
+		}
+	}
+
+	for name, cred := range c.Credentials {
+		validateCredentials("credentials."+name, cred, invalid)
+	}
+
+	for _, name := range c.Metadata.Dynamic {
+		if !slices.Contains(MetadataProviders, name) {
+			invalid("metadata.dynamic", "unknown provider %q, want one of %v", name, MetadataProviders)
+		}
+	}
+
+	for name, svc := range c.Services {
+		if svc.Timeout < 0 {
+			invalid("services."+name+".timeout", "must not be negative, got %v", svc.Timeout)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateCredentials(prefix string, c CredentialsConfig,
+	invalid func(field, format string, args ...interface{})) {
+	switch c.Type {
+	case "bearer", "api_key":
+	case "oauth2":
+		if u, err := url.Parse(c.OAuth2.TokenURL); err != nil || u.Scheme == "" || u.Host == "" {
+			invalid(prefix+".oauth2.token_url", "must be an absolute URL, got %q", c.OAuth2.TokenURL)
+		}
+
+		if c.OAuth2.ClientID == "" {
+			invalid(prefix+".oauth2.client_id", "must not be empty")
+		}
+
+		if c.OAuth2.RefreshEarly < 0 {
+			invalid(prefix+".oauth2.refresh_early", "must not be negative, got %v", c.OAuth2.RefreshEarly)
+		}
+	default:
+		invalid(prefix+".type", "must be bearer, api_key or oauth2, got %q", c.Type)
+	}
+
+	sources := 0
+	for _, s := range []string{c.Token, c.File, c.Env} {
+		if s != "" {
+			sources++
+		}
+	}
+
+	if sources != 1 {
+		invalid(prefix, "must set exactly one of token, file and env")
+	}
+
+	if c.RefreshInterval < 0 {
+		invalid(prefix+".refresh_interval", "must not be negative, got %v", c.RefreshInterval)
+	}
+}
+
+// validPattern reports whether pattern is "/<service>/<method>" or
+// "/<service>/*".
+func validPattern(pattern string) bool {
+	parts := strings.Split(pattern, "/")
+
+	return len(parts) == 3 && parts[0] == "" && parts[1] != "" && parts[2] != ""
+}
+
+func validateTimeouts(prefix string, t TimeoutConfig,
+	invalid func(field, format string, args ...interface{})) {
+	if t.UnaryTimeout <= 0 {
+		invalid(prefix+"unary_timeout", "must be positive, got %v", t.UnaryTimeout)
+	}
+
+	if t.StreamTimeout <= 0 {
+		invalid(prefix+"stream_timeout", "must be positive, got %v", t.StreamTimeout)
+	}
+
+	if t.StreamIdleTimeout < 0 {
+		invalid(prefix+"stream_idle_timeout", "must not be negative, got %v", t.StreamIdleTimeout)
+	}
+}
+
+func validateBreakerPolicy(prefix string, p BreakerPolicyConfig,
+	invalid func(field, format string, args ...interface{})) {
+	if p.MinRequests == 0 {
+		invalid(prefix+"min_requests", "must be at least 1")
+	}
+
+	if p.FailureRatio <= 0 || p.FailureRatio > 1 {
+		invalid(prefix+"failure_ratio", "must be in (0, 1], got %v", p.FailureRatio)
+	}
+
+	if p.Timeout <= 0 {
+		invalid(prefix+"timeout", "must be positive, got %v", p.Timeout)
+	}
+
+	if p.Interval < 0 {
+		invalid(prefix+"interval", "must not be negative, got %v", p.Interval)
+	}
+
+	if _, err := p.FailureStatusCodes(); err != nil {
+		invalid(prefix+"failure_codes", "%v", err)
+	}
+}
+
+func validateRetryPolicy(field string, p RetryPolicyConfig,
+	invalid func(field, format string, args ...interface{})) {
+	if p.MaxAttempts < 1 {
+		invalid(field+".max_attempts", "must be at least 1, got %v", p.MaxAttempts)
+	}
+
+	if _, err := p.StatusCodes(); err != nil {
+		invalid(field+".codes", "%v", err)
+	}
+
+	if p.InitialBackoff < 0 || p.MaxBackoff < 0 {
+		invalid(field, "backoffs must not be negative, got %v and %v", p.InitialBackoff, p.MaxBackoff)
+	}
+
+	if p.Multiplier != 0 && p.Multiplier < 1 {
+		invalid(field+".multiplier", "must be at least 1, got %v", p.Multiplier)
+	}
+
+	if p.Jitter < 0 || p.Jitter > 1 {
+		invalid(field+".jitter", "must be in [0, 1], got %v", p.Jitter)
+	}
+}
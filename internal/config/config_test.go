@@ -0,0 +1,245 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPolicyMerge(t *testing.T) {
+	cfg := Default()
+	cfg.Policies = map[string]PolicyConfig{
+		"/bank.BankService/*": {
+			TimeoutConfig: TimeoutConfig{UnaryTimeout: Duration(2 * time.Second)},
+			Metadata:      map[string]string{"x-service": "bank", "x-level": "service"},
+			Credentials:   "bank",
+		},
+		"/bank.BankService/GetCurrentBalance": {
+			Retry:       RetryPolicyConfig{MaxAttempts: 5},
+			Metadata:    map[string]string{"x-level": "method"},
+			Credentials: CredentialsNone,
+		},
+	}
+
+	tests := []struct {
+		method          string
+		wantTimeout     time.Duration
+		wantAttempts    int
+		wantMetadata    map[string]string
+		wantCredentials string
+	}{
+		{"/bank.BankService/GetCurrentBalance", 2 * time.Second, 5,
+			map[string]string{"x-service": "bank", "x-level": "method"}, CredentialsNone},
+		{"/bank.BankService/FetchExchangeRates", 2 * time.Second, 3,
+			map[string]string{"x-service": "bank", "x-level": "service"}, "bank"},
+		{"/bank.BankService/*", 2 * time.Second, 3,
+			map[string]string{"x-service": "bank", "x-level": "service"}, "bank"},
+		{"/hello.HelloService/SayHello", 5 * time.Second, 3, map[string]string{}, ""},
+	}
+
+	for _, tt := range tests {
+		p := cfg.Policy(tt.method)
+
+		if got := time.Duration(p.UnaryTimeout); got != tt.wantTimeout {
+			t.Errorf("Policy(%v).UnaryTimeout = %v, want %v", tt.method, got, tt.wantTimeout)
+		}
+
+		if got := time.Duration(p.StreamTimeout); got != 20*time.Second {
+			t.Errorf("Policy(%v).StreamTimeout = %v, want the default 20s", tt.method, got)
+		}
+
+		if p.Retry.MaxAttempts != tt.wantAttempts {
+			t.Errorf("Policy(%v).Retry.MaxAttempts = %v, want %v", tt.method, p.Retry.MaxAttempts, tt.wantAttempts)
+		}
+
+		if !reflect.DeepEqual(p.Retry.Codes, []string{"UNAVAILABLE"}) {
+			t.Errorf("Policy(%v).Retry.Codes = %v, want the default codes", tt.method, p.Retry.Codes)
+		}
+
+		if !reflect.DeepEqual(p.Metadata, tt.wantMetadata) {
+			t.Errorf("Policy(%v).Metadata = %v, want %v", tt.method, p.Metadata, tt.wantMetadata)
+		}
+
+		if p.Credentials != tt.wantCredentials {
+			t.Errorf("Policy(%v).Credentials = %q, want %q", tt.method, p.Credentials, tt.wantCredentials)
+		}
+	}
+
+	// the merge must not write to the policies
+	if got := cfg.Policies["/bank.BankService/*"].Metadata["x-level"]; got != "service" {
+		t.Errorf("service metadata changed to %q by the merge", got)
+	}
+}
+
+// fieldsOf returns the fields of the FieldErrors joined in err.
+func fieldsOf(t *testing.T, err error) []string {
+	t.Helper()
+
+	var errs []error
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	} else if err != nil {
+		errs = []error{err}
+	}
+
+	var fields []string
+	for _, err := range errs {
+		var fe *FieldError
+		if !errors.As(err, &fe) {
+			t.Fatalf("Validate returned %T %v, want a *FieldError", err, err)
+		}
+
+		fields = append(fields, fe.Field)
+	}
+
+	return fields
+}
+
+// validConfig returns the defaults without their policies, which would
+// report the errors they inherit once more.
+func validConfig() *Config {
+	c := Default()
+	c.Policies = nil
+
+	return c
+}
+
+func TestValidate(t *testing.T) {
+	const method = "/bank.BankService/GetCurrentBalance"
+
+	policy := func(c *Config, p PolicyConfig) {
+		c.Policies = map[string]PolicyConfig{method: p}
+	}
+
+	oauth2 := func(c *Config, f func(cred *CredentialsConfig)) {
+		cred := CredentialsConfig{
+			Type: "oauth2",
+			Env:  "BANK_CLIENT_SECRET",
+			OAuth2: OAuth2Config{
+				TokenURL: "https://auth.example.com/oauth2/token",
+				ClientID: "cli",
+			},
+		}
+		f(&cred)
+		c.Credentials = map[string]CredentialsConfig{"bank": cred}
+	}
+
+	tests := []struct {
+		name      string
+		change    func(c *Config)
+		wantField string
+	}{
+		{"defaults", func(c *Config) {}, ""},
+		{"empty target", func(c *Config) { c.Target = "" }, "target"},
+		{"insecure with a CA", func(c *Config) { c.TLS.Insecure = true; c.TLS.CAFile = "ca.pem" }, "tls.insecure"},
+		{"cert without key", func(c *Config) { c.TLS.CertFile = "client.pem" }, "tls.cert_file"},
+		{"key without cert", func(c *Config) { c.TLS.KeyFile = "client-key.pem" }, "tls.cert_file"},
+		{"negative reload interval", func(c *Config) { c.TLS.ReloadInterval = -1 }, "tls.reload_interval"},
+		{"zero unary timeout", func(c *Config) { c.Interceptor.UnaryTimeout = 0 }, "interceptor.unary_timeout"},
+		{"zero stream timeout", func(c *Config) { c.Interceptor.StreamTimeout = 0 }, "interceptor.stream_timeout"},
+		{"negative idle timeout", func(c *Config) { c.Interceptor.StreamIdleTimeout = -1 },
+			"interceptor.stream_idle_timeout"},
+		{"zero min requests", func(c *Config) { c.CircuitBreaker.MinRequests = 0 }, "circuit_breaker.min_requests"},
+		{"failure ratio above 1", func(c *Config) { c.CircuitBreaker.FailureRatio = 1.5 },
+			"circuit_breaker.failure_ratio"},
+		{"zero breaker timeout", func(c *Config) { c.CircuitBreaker.Timeout = 0 }, "circuit_breaker.timeout"},
+		{"negative breaker interval", func(c *Config) { c.CircuitBreaker.Interval = -1 }, "circuit_breaker.interval"},
+		{"unknown failure code", func(c *Config) { c.CircuitBreaker.FailureCodes = []string{"NOPE"} },
+			"circuit_breaker.failure_codes"},
+		{"unknown breaker scope", func(c *Config) { c.CircuitBreaker.Scope = "host" }, "circuit_breaker.scope"},
+		{"zero max attempts", func(c *Config) { c.Retry.MaxAttempts = 0 }, "retry.max_attempts"},
+		{"unknown retry code", func(c *Config) { c.Retry.Codes = []string{"unavailable", "NOPE"} }, "retry.codes"},
+		{"negative backoff", func(c *Config) { c.Retry.MaxBackoff = -1 }, "retry"},
+		{"multiplier below 1", func(c *Config) { c.Retry.Multiplier = 0.5 }, "retry.multiplier"},
+		{"jitter above 1", func(c *Config) { c.Retry.Jitter = 2 }, "retry.jitter"},
+		{"policy pattern", func(c *Config) { c.Policies = map[string]PolicyConfig{"bank.BankService": {}} },
+			"policies.bank.BankService"},
+		{"policy timeout", func(c *Config) {
+			policy(c, PolicyConfig{TimeoutConfig: TimeoutConfig{UnaryTimeout: -1}})
+		}, "policies." + method + ".unary_timeout"},
+		{"policy retry", func(c *Config) {
+			policy(c, PolicyConfig{Retry: RetryPolicyConfig{MaxAttempts: -1}})
+		}, "policies." + method + ".retry.max_attempts"},
+		{"policy breaker", func(c *Config) {
+			policy(c, PolicyConfig{CircuitBreaker: BreakerPolicyConfig{FailureRatio: 2}})
+		}, "policies." + method + ".circuit_breaker.failure_ratio"},
+		{"policy unknown credentials", func(c *Config) { policy(c, PolicyConfig{Credentials: "bank"}) },
+			"policies." + method + ".credentials"},
+		{"policy without credentials", func(c *Config) { policy(c, PolicyConfig{Credentials: CredentialsNone}) }, ""},
+		{"oauth2 credentials", func(c *Config) { oauth2(c, func(*CredentialsConfig) {}) }, ""},
+		{"unknown credentials type", func(c *Config) { oauth2(c, func(cred *CredentialsConfig) { cred.Type = "basic" }) },
+			"credentials.bank.type"},
+		{"relative token url", func(c *Config) {
+			oauth2(c, func(cred *CredentialsConfig) { cred.OAuth2.TokenURL = "/oauth2/token" })
+		}, "credentials.bank.oauth2.token_url"},
+		{"empty client id", func(c *Config) {
+			oauth2(c, func(cred *CredentialsConfig) { cred.OAuth2.ClientID = "" })
+		}, "credentials.bank.oauth2.client_id"},
+		{"negative refresh early", func(c *Config) {
+			oauth2(c, func(cred *CredentialsConfig) { cred.OAuth2.RefreshEarly = -1 })
+		}, "credentials.bank.oauth2.refresh_early"},
+		{"two secret sources", func(c *Config) {
+			oauth2(c, func(cred *CredentialsConfig) { cred.File = "secret" })
+		}, "credentials.bank"},
+		{"no secret source", func(c *Config) {
+			oauth2(c, func(cred *CredentialsConfig) { cred.Env = "" })
+		}, "credentials.bank"},
+		{"negative refresh interval", func(c *Config) {
+			oauth2(c, func(cred *CredentialsConfig) { cred.RefreshInterval = -1 })
+		}, "credentials.bank.refresh_interval"},
+		{"unknown dynamic metadata", func(c *Config) { c.Metadata.Dynamic = []string{"client-host"} },
+			"metadata.dynamic"},
+		{"negative service timeout", func(c *Config) { c.Services["bank"] = ServiceConfig{Timeout: -1} },
+			"services.bank.timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.change(cfg)
+
+			fields := fieldsOf(t, cfg.Validate())
+
+			var want []string
+			if tt.wantField != "" {
+				want = []string{tt.wantField}
+			}
+
+			if !reflect.DeepEqual(fields, want) {
+				t.Errorf("Validate reported %v, want %v", fields, want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryField(t *testing.T) {
+	cfg := validConfig()
+	cfg.Target = ""
+	cfg.Retry.Jitter = -1
+	cfg.Services["hello"] = ServiceConfig{Timeout: -1}
+
+	fields := fieldsOf(t, cfg.Validate())
+	want := []string{"target", "retry.jitter", "services.hello.timeout"}
+
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Validate reported %v, want %v", fields, want)
+	}
+}
+
+func TestOAuth2EarlyRefresh(t *testing.T) {
+	tests := []struct {
+		early Duration
+		want  time.Duration
+	}{
+		{0, 30 * time.Second},
+		{Duration(time.Minute), time.Minute},
+		{Duration(5 * time.Second), 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := (OAuth2Config{RefreshEarly: tt.early}).EarlyRefresh(); got != tt.want {
+			t.Errorf("EarlyRefresh with refresh_early %v = %v, want %v", tt.early, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is prepended to every environment variable read by Load.
+const EnvPrefix = "GRPC_CLIENT_"
+
+// services with a timeout that can be set from the environment or flags;
+// the config file accepts any service name.
+var knownServices = []string{"hello", "bank", "resiliency"}
+
+// setting is a single value that can be overridden from the environment
+// and the command line. The environment variable is EnvPrefix followed by
+// the upper-cased key with dashes replaced by underscores.
+type setting struct {
+	key   string
+	usage string
+	set   func(c *Config, value string) error
+	// isBool lets the flag be given without a value, meaning true.
+	isBool bool
+}
+
+func (s setting) env() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(s.key, "-", "_"))
+}
+
+func settings() []setting {
+	list := []setting{
+		{
+			key: "target", usage: "address of the gRPC server",
+			set: func(c *Config, v string) error { c.Target = v; return nil },
+		},
+		{
+			key: "insecure", usage: "dial without TLS, for local development", isBool: true,
+			set: func(c *Config, v string) error { return setBool(&c.TLS.Insecure, v) },
+		},
+		{
+			key: "tls-ca-file", usage: "PEM bundle of the CAs trusted to sign the server certificate",
+			set: func(c *Config, v string) error { c.TLS.CAFile = v; return nil },
+		},
+		{
+			key: "tls-cert-file", usage: "PEM client certificate for mutual TLS",
+			set: func(c *Config, v string) error { c.TLS.CertFile = v; return nil },
+		},
+		{
+			key: "tls-key-file", usage: "PEM key of the client certificate",
+			set: func(c *Config, v string) error { c.TLS.KeyFile = v; return nil },
+		},
+		{
+			key: "tls-server-name", usage: "name checked against the server certificate instead of the target host",
+			set: func(c *Config, v string) error { c.TLS.ServerName = v; return nil },
+		},
+		{
+			key: "tls-reload-interval", usage: "how often the TLS files are checked for changes, 0 never reloads them",
+			set: func(c *Config, v string) error { return c.TLS.ReloadInterval.set(v) },
+		},
+		{
+			key: "unary-timeout", usage: "timeout applied to every unary call",
+			set: func(c *Config, v string) error { return c.Interceptor.UnaryTimeout.set(v) },
+		},
+		{
+			key: "stream-timeout", usage: "cap on the lifetime of every stream",
+			set: func(c *Config, v string) error { return c.Interceptor.StreamTimeout.set(v) },
+		},
+		{
+			key: "stream-idle-timeout", usage: "time a stream may go without a message, 0 means no limit",
+			set: func(c *Config, v string) error { return c.Interceptor.StreamIdleTimeout.set(v) },
+		},
+		{
+			key: "log-payloads", usage: "log the messages of every call, with sensitive fields masked", isBool: true,
+			set: func(c *Config, v string) error { return setBool(&c.Logging.Payloads, v) },
+		},
+		{
+			key: "breaker-min-requests", usage: "requests seen before the circuit breaker may trip",
+			set: func(c *Config, v string) error { return setUint32(&c.CircuitBreaker.MinRequests, v) },
+		},
+		{
+			key: "breaker-failure-ratio", usage: "failure ratio that trips the circuit breaker",
+			set: func(c *Config, v string) error {
+				f, err := strconv.ParseFloat(v, 64)
+				c.CircuitBreaker.FailureRatio = f
+				return err
+			},
+		},
+		{
+			key: "breaker-timeout", usage: "how long the circuit breaker stays open",
+			set: func(c *Config, v string) error { return c.CircuitBreaker.Timeout.set(v) },
+		},
+		{
+			key: "breaker-max-requests", usage: "requests let through while the circuit breaker is half-open",
+			set: func(c *Config, v string) error { return setUint32(&c.CircuitBreaker.MaxRequests, v) },
+		},
+		{
+			key: "breaker-scope", usage: "method for a circuit breaker per method, target for one per server",
+			set: func(c *Config, v string) error { c.CircuitBreaker.Scope = v; return nil },
+		},
+		{
+			key: "retry-max-attempts", usage: "attempts made for a call, 1 disables retries",
+			set: func(c *Config, v string) error {
+				n, err := strconv.Atoi(v)
+				c.Retry.MaxAttempts = n
+				return err
+			},
+		},
+		{
+			key: "retry-codes", usage: "comma separated status codes that are retried, empty retries none",
+			set: func(c *Config, v string) error {
+				c.Retry.Codes = nil
+				if v != "" {
+					for _, code := range strings.Split(v, ",") {
+						c.Retry.Codes = append(c.Retry.Codes, strings.TrimSpace(code))
+					}
+				}
+				return nil
+			},
+		},
+		{
+			key: "metadata-dynamic", usage: "comma separated metadata computed for every call, out of " +
+				strings.Join(MetadataProviders, ", "),
+			set: func(c *Config, v string) error {
+				c.Metadata.Dynamic = nil
+				if v != "" {
+					c.Metadata.Dynamic = strings.Split(v, ",")
+				}
+				return nil
+			},
+		},
+	}
+
+	for _, name := range knownServices {
+		name := name
+
+		list = append(list, setting{
+			key: name + "-timeout", usage: "timeout for " + name + " commands",
+			set: func(c *Config, v string) error {
+				svc := c.Services[name]
+				if err := svc.Timeout.set(v); err != nil {
+					return err
+				}
+
+				c.Services[name] = svc
+				return nil
+			},
+		})
+	}
+
+	return list
+}
+
+func setBool(dst *bool, value string) error {
+	v, err := strconv.ParseBool(value)
+	*dst = v
+
+	return err
+}
+
+func setUint32(dst *uint32, value string) error {
+	v, err := strconv.ParseUint(value, 10, 32)
+	*dst = uint32(v)
+
+	return err
+}
+
+// Flags holds the command line overrides registered by BindFlags.
+type Flags struct {
+	path   string
+	values map[string]*string
+	fs     *flag.FlagSet
+}
+
+// BindFlags registers -config and one flag per overridable setting on fs.
+func BindFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{
+		values: map[string]*string{},
+		fs:     fs,
+	}
+
+	fs.StringVar(&f.path, "config", "", "path to a YAML or JSON config file (env "+EnvPrefix+"CONFIG)")
+
+	for _, s := range settings() {
+		usage := s.usage + " (env " + s.env() + ")"
+
+		if s.isBool {
+			v := new(string)
+			fs.Var((*boolString)(v), s.key, usage)
+			f.values[s.key] = v
+			continue
+		}
+
+		f.values[s.key] = fs.String(s.key, "", usage)
+	}
+
+	return f
+}
+
+// boolString is a string flag that can be given without a value, like a
+// bool flag, which then is "true".
+type boolString string
+
+func (b *boolString) String() string {
+	if b == nil {
+		return ""
+	}
+
+	return string(*b)
+}
+
+func (b *boolString) Set(s string) error {
+	*b = boolString(s)
+	return nil
+}
+
+func (b *boolString) IsBoolFlag() bool {
+	return true
+}
+
+// Load builds the configuration from, in increasing order of precedence,
+// the defaults, the config file, the environment and the command line
+// flags. Flags may be nil. The result is validated.
+func Load(flags *Flags) (*Config, error) {
+	return load(flags, os.LookupEnv)
+}
+
+func load(flags *Flags, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+
+	path, _ := lookupEnv(EnvPrefix + "CONFIG")
+	if flags != nil && flags.path != "" {
+		path = flags.path
+	}
+
+	if path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings() {
+		if v, ok := lookupEnv(s.env()); ok {
+			if err := s.set(cfg, v); err != nil {
+				return nil, fmt.Errorf("config: invalid %v=%q: %w", s.env(), v, err)
+			}
+		}
+	}
+
+	if flags != nil {
+		set := map[string]bool{}
+		flags.fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+		for _, s := range settings() {
+			if !set[s.key] {
+				continue
+			}
+
+			v := *flags.values[s.key]
+			if err := s.set(cfg, v); err != nil {
+				return nil, fmt.Errorf("config: invalid -%v=%q: %w", s.key, v, err)
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// readFile decodes path over c, choosing JSON or YAML by file extension.
+// Unknown keys are rejected so typos do not go unnoticed. The policies of
+// the file are merged into the ones of c field by field, as decoding would
+// replace a whole policy, dropping e.g. the retry settings of a default
+// policy when the file only sets its timeout.
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	policies := c.Policies
+	c.Policies = nil
+	defer func() { c.Policies = mergePolicies(policies, c.Policies) }()
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(c)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(c)
+	default:
+		return fmt.Errorf("config: unsupported file extension %q, want .json, .yaml or .yml", ext)
+	}
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config: parse %v: %w", path, err)
+	}
+
+	if c.Services == nil {
+		c.Services = map[string]ServiceConfig{}
+	}
+
+	return nil
+}
+
+// mergePolicies returns base with every policy of file merged over the
+// policy of base with the same key.
+func mergePolicies(base, file map[string]PolicyConfig) map[string]PolicyConfig {
+	merged := map[string]PolicyConfig{}
+	for pattern, p := range base {
+		merged[pattern] = p
+	}
+
+	for pattern, p := range file {
+		if b, ok := merged[pattern]; ok {
+			p = b.merge(p)
+		}
+
+		merged[pattern] = p
+	}
+
+	return merged
+}
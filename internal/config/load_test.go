@@ -0,0 +1,275 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig writes content to a file called name in a temporary
+// directory and returns its path.
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// envOf returns a lookupEnv reading env.
+func envOf(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+// loadWith loads the config with env and the command line args.
+func loadWith(t *testing.T, env map[string]string, args ...string) (*Config, error) {
+	t.Helper()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := BindFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("Parse(%v): %v", args, err)
+	}
+
+	return load(flags, envOf(env))
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeConfig(t, "config.yaml", "target: file:9090\ninterceptor:\n  unary_timeout: 2s\n")
+	other := writeConfig(t, "other.json", `{"target": "other:9090"}`)
+
+	tests := []struct {
+		name        string
+		env         map[string]string
+		args        []string
+		wantTarget  string
+		wantTimeout time.Duration
+	}{
+		{"defaults", nil, nil, "localhost:9090", 5 * time.Second},
+		{"file over defaults", map[string]string{"GRPC_CLIENT_CONFIG": file}, nil, "file:9090", 2 * time.Second},
+		{"config flag over env", map[string]string{"GRPC_CLIENT_CONFIG": file}, []string{"-config", other},
+			"other:9090", 5 * time.Second},
+		{"env over file", map[string]string{"GRPC_CLIENT_CONFIG": file, "GRPC_CLIENT_TARGET": "env:9090"}, nil,
+			"env:9090", 2 * time.Second},
+		{"flags over env", map[string]string{"GRPC_CLIENT_TARGET": "env:9090", "GRPC_CLIENT_UNARY_TIMEOUT": "3s"},
+			[]string{"-config", file, "-target", "flag:9090"}, "flag:9090", 3 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadWith(t, tt.env, tt.args...)
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+
+			if cfg.Target != tt.wantTarget {
+				t.Errorf("Target = %q, want %q", cfg.Target, tt.wantTarget)
+			}
+
+			if got := time.Duration(cfg.Interceptor.UnaryTimeout); got != tt.wantTimeout {
+				t.Errorf("UnaryTimeout = %v, want %v", got, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestLoadSettings(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		args  []string
+		check func(c *Config) bool
+	}{
+		{"bool flag without a value", nil, []string{"-insecure"},
+			func(c *Config) bool { return c.TLS.Insecure }},
+		{"bool flag set to false", map[string]string{"GRPC_CLIENT_INSECURE": "true"}, []string{"-insecure=false"},
+			func(c *Config) bool { return !c.TLS.Insecure }},
+		{"bool env", map[string]string{"GRPC_CLIENT_LOG_PAYLOADS": "1"}, nil,
+			func(c *Config) bool { return c.Logging.Payloads }},
+		{"duration", nil, []string{"-stream-idle-timeout", "1m30s"},
+			func(c *Config) bool { return c.Interceptor.StreamIdleTimeout == Duration(90*time.Second) }},
+		{"service timeout", map[string]string{"GRPC_CLIENT_BANK_TIMEOUT": "10s"}, nil,
+			func(c *Config) bool { return c.ServiceTimeout("bank") == 10*time.Second }},
+		{"empty list", nil, []string{"-metadata-dynamic", ""},
+			func(c *Config) bool { return len(c.Metadata.Dynamic) == 0 }},
+		{"codes", map[string]string{"GRPC_CLIENT_RETRY_CODES": "unavailable, RESOURCE_EXHAUSTED"}, nil,
+			func(c *Config) bool {
+				return reflect.DeepEqual(c.Retry.Codes, []string{"unavailable", "RESOURCE_EXHAUSTED"})
+			}},
+		{"no codes", nil, []string{"-retry-codes", ""},
+			func(c *Config) bool { return c.Retry.Codes == nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadWith(t, tt.env, tt.args...)
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+
+			if !tt.check(cfg) {
+				t.Errorf("setting not applied, got %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestLoadInvalidValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		wantErr string
+	}{
+		{"duration without unit", map[string]string{"GRPC_CLIENT_UNARY_TIMEOUT": "5"}, nil,
+			"GRPC_CLIENT_UNARY_TIMEOUT"},
+		{"duration flag", nil, []string{"-stream-timeout", "soon"}, "-stream-timeout"},
+		{"bool env", map[string]string{"GRPC_CLIENT_INSECURE": "maybe"}, nil, "GRPC_CLIENT_INSECURE"},
+		{"bool flag", nil, []string{"-log-payloads=yes"}, "-log-payloads"},
+		{"negative count", map[string]string{"GRPC_CLIENT_BREAKER_MIN_REQUESTS": "-1"}, nil,
+			"GRPC_CLIENT_BREAKER_MIN_REQUESTS"},
+		{"ratio", nil, []string{"-breaker-failure-ratio", "half"}, "-breaker-failure-ratio"},
+		{"service timeout", map[string]string{"GRPC_CLIENT_HELLO_TIMEOUT": "1x"}, nil, "GRPC_CLIENT_HELLO_TIMEOUT"},
+		{"parsed but invalid", nil, []string{"-retry-max-attempts", "0"}, "retry.max_attempts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadWith(t, tt.env, tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("load error = %v, want one about %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	tests := []struct {
+		name, file, content string
+		wantErr             string
+	}{
+		{"yaml", "c.yaml", "retry:\n  max_attempts: 2\n  initial_backoff: 50ms\n", ""},
+		{"yml", "c.yml", "target: yml:9090\n", ""},
+		{"json", "c.json", `{"retry": {"max_attempts": 2, "initial_backoff": "50ms"}}`, ""},
+		{"empty yaml", "c.yaml", "", ""},
+		{"unknown yaml field", "c.yaml", "targt: x:9090\n", "targt"},
+		{"unknown nested yaml field", "c.yaml", "retry:\n  max_attempt: 2\n", "max_attempt"},
+		{"unknown json field", "c.json", `{"targt": "x:9090"}`, "targt"},
+		{"unknown nested json field", "c.json", `{"tls": {"insecur": true}}`, "insecur"},
+		{"yaml duration without unit", "c.yaml", "interceptor:\n  unary_timeout: 5\n", "missing unit"},
+		{"json duration as a number", "c.json", `{"interceptor": {"unary_timeout": 5}}`, "duration must be a string"},
+		{"unsupported extension", "c.toml", "target = \"x\"\n", "unsupported file extension"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			err := cfg.readFile(writeConfig(t, tt.file, tt.content))
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("readFile: %v", err)
+				}
+
+				if cfg.Services == nil {
+					t.Error("Services is nil after reading the file")
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("readFile error = %v, want one about %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadFileMissing(t *testing.T) {
+	if err := Default().readFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("readFile of a missing file succeeded")
+	}
+}
+
+func TestExampleConfig(t *testing.T) {
+	if _, err := loadWith(t, nil, "-config", filepath.Join("..", "..", "config.example.yaml")); err != nil {
+		t.Fatalf("load config.example.yaml: %v", err)
+	}
+}
+
+func TestCreateAccountNotRetried(t *testing.T) {
+	const createAccount = "/bank.BankService/CreateAccount"
+
+	policies := writeConfig(t, "policies.yaml",
+		"policies:\n  /bank.BankService/*:\n    unary_timeout: 2s\n")
+	timeoutOnly := writeConfig(t, "create.yaml",
+		"policies:\n  /bank.BankService/CreateAccount:\n    unary_timeout: 1s\n")
+	timeoutOnlyJSON := writeConfig(t, "create.json",
+		`{"policies": {"/bank.BankService/CreateAccount": {"unary_timeout": "1s"}}}`)
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"defaults", nil},
+		{"example config", []string{"-config", filepath.Join("..", "..", "config.example.yaml")}},
+		{"file with other policies", []string{"-config", policies}},
+		{"file setting only the timeout of CreateAccount", []string{"-config", timeoutOnly}},
+		{"json file setting only the timeout of CreateAccount", []string{"-config", timeoutOnlyJSON}},
+		{"retries enabled for every method", []string{"-retry-max-attempts", "5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadWith(t, nil, tt.args...)
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+
+			if got := cfg.Policy(createAccount).Retry.MaxAttempts; got != 1 {
+				t.Errorf("%v max_attempts = %d, want 1", createAccount, got)
+			}
+
+			if got := cfg.Policy("/bank.BankService/GetCurrentBalance").Retry.MaxAttempts; got < 2 {
+				t.Errorf("GetCurrentBalance max_attempts = %d, want retries", got)
+			}
+		})
+	}
+}
+
+func TestLoadMergesFilePolicies(t *testing.T) {
+	const createAccount = "/bank.BankService/CreateAccount"
+
+	file := writeConfig(t, "c.yaml", "policies:\n  /bank.BankService/CreateAccount:\n    unary_timeout: 1s\n")
+
+	cfg, err := loadWith(t, nil, "-config", file)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	p := cfg.Policy(createAccount)
+	if p.UnaryTimeout != Duration(time.Second) || p.Retry.MaxAttempts != 1 {
+		t.Errorf("%v = %+v, want the timeout of the file and the retries of the default policy", createAccount, p)
+	}
+
+	// a file setting the field wins over the default policy
+	file = writeConfig(t, "c.yaml", "policies:\n  /bank.BankService/CreateAccount:\n    retry:\n      max_attempts: 2\n")
+
+	cfg, err = loadWith(t, nil, "-config", file)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	if got := cfg.Policy(createAccount).Retry.MaxAttempts; got != 2 {
+		t.Errorf("%v max_attempts = %d, want the 2 of the file", createAccount, got)
+	}
+}
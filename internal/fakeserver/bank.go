@@ -0,0 +1,172 @@
+package fakeserver
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/bank"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/type/date"
+	"google.golang.org/genproto/googleapis/type/datetime"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	// Balance is the amount of every account.
+	Balance = 1500.75
+	// Rate is the rate of every currency pair.
+	Rate = 15000.5
+)
+
+// Day is the date of every balance, summary and transfer, at midnight UTC.
+var Day = time.Date(2023, time.May, 17, 0, 0, 0, 0, time.UTC)
+
+// Bank is a fake bank.BankService answering with Balance, Rate and Day.
+type Bank struct {
+	bank.UnimplementedBankServiceServer
+	fake
+}
+
+func toProtoDate(t time.Time) *date.Date {
+	return &date.Date{Year: int32(t.Year()), Month: int32(t.Month()), Day: int32(t.Day())}
+}
+
+func toProtoDateTime(t time.Time) *datetime.DateTime {
+	return &datetime.DateTime{
+		Year:       int32(t.Year()),
+		Month:      int32(t.Month()),
+		Day:        int32(t.Day()),
+		Hours:      int32(t.Hour()),
+		Minutes:    int32(t.Minute()),
+		Seconds:    int32(t.Second()),
+		TimeOffset: &datetime.DateTime_UtcOffset{UtcOffset: durationpb.New(0)},
+	}
+}
+
+func (b *Bank) GetCurrentBalance(ctx context.Context, req *bank.CurrentBalanceRequest) (*bank.CurrentBalanceResponse, error) {
+	bh, err := b.begin(ctx, "GetCurrentBalance")
+	if err != nil {
+		return nil, err
+	}
+
+	if bh.Err != nil {
+		return nil, bh.Err
+	}
+
+	return &bank.CurrentBalanceResponse{Amount: Balance, CurrentDate: toProtoDate(Day)}, nil
+}
+
+// FetchExchangeRates sends StreamLength rates, one second apart from Day.
+func (b *Bank) FetchExchangeRates(req *bank.ExchangeRateRequest, stream bank.BankService_FetchExchangeRatesServer) error {
+	bh, err := b.begin(stream.Context(), "FetchExchangeRates")
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < StreamLength; i++ {
+		if bh.Err != nil && i == bh.FailAfter {
+			return bh.Err
+		}
+
+		rate := &bank.ExchangeRateResponse{
+			FromCurrency: req.FromCurrency,
+			ToCurrency:   req.ToCurrency,
+			Rate:         Rate,
+			Timestamp:    Day.Add(time.Duration(i) * time.Second).Format(time.RFC3339),
+		}
+
+		if err := stream.Send(rate); err != nil {
+			return err
+		}
+	}
+
+	return bh.Err
+}
+
+// SummarizeTransactions sums the received transactions, all expected to be
+// of the same account.
+func (b *Bank) SummarizeTransactions(stream bank.BankService_SummarizeTransactionsServer) error {
+	bh, err := b.begin(stream.Context(), "SummarizeTransactions")
+	if err != nil {
+		return err
+	}
+
+	summary := &bank.TransactionSummary{TransactionDate: toProtoDate(Day)}
+	for {
+		tx, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		summary.AccountNumber = tx.AccountNumber
+
+		switch tx.Type {
+		case bank.TransactionType_TRANSACTION_TYPE_IN:
+			summary.SumAmountIn += tx.Amount
+		case bank.TransactionType_TRANSACTION_TYPE_OUT:
+			summary.SumAmountOut += tx.Amount
+		}
+	}
+
+	if bh.Err != nil {
+		return bh.Err
+	}
+
+	summary.SumTotal = summary.SumAmountIn - summary.SumAmountOut
+
+	return stream.SendAndClose(summary)
+}
+
+// TransferMultiple answers every received transfer as successful.
+func (b *Bank) TransferMultiple(stream bank.BankService_TransferMultipleServer) error {
+	bh, err := b.begin(stream.Context(), "TransferMultiple")
+	if err != nil {
+		return err
+	}
+
+	for i := 0; ; i++ {
+		if bh.Err != nil && i == bh.FailAfter {
+			return bh.Err
+		}
+
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		res := &bank.TransferResponse{
+			FromAccountNumber: req.FromAccountNumber,
+			ToAccountNumber:   req.ToAccountNumber,
+			Currency:          req.Currency,
+			Amount:            req.Amount,
+			Status:            bank.TransferStatus_TRANSFER_STATUS_SUCCESS,
+			Timestamp:         toProtoDateTime(Day),
+		}
+
+		if err := stream.Send(res); err != nil {
+			return err
+		}
+	}
+}
+
+func (b *Bank) CreateAccount(ctx context.Context, req *bank.CreateAccountRequest) (*bank.CreateAccountResponse, error) {
+	bh, err := b.begin(ctx, "CreateAccount")
+	if err != nil {
+		return nil, err
+	}
+
+	if bh.Err != nil {
+		return nil, bh.Err
+	}
+
+	return &bank.CreateAccountResponse{AccountUuid: uuid.NewString()}, nil
+}
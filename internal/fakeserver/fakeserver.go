@@ -0,0 +1,159 @@
+// Package fakeserver runs fake Hello, Bank and Resiliency services on an
+// in-process bufconn listener, so adapters and interceptors can be tested end
+// to end without a network.
+package fakeserver
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/bank"
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/hello"
+	resl "github.com/fbriansyah/my-grpc-proto/protogen/go/resiliency"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// StreamLength is the number of messages sent by the fake server streams.
+const StreamLength = 3
+
+// Behavior scripts how a fake answers the calls of one method.
+type Behavior struct {
+	// Delay is waited before answering, or before the first message of a
+	// stream. A call cancelled while waiting ends with the context error.
+	Delay time.Duration
+	// Err ends the call instead of the normal answer, build it with
+	// status.New(...).WithDetails(...).Err() to send error details.
+	Err error
+	// FailAfter is the number of messages a server or bidi stream sends
+	// before it ends with Err.
+	FailAfter int
+	Header    metadata.MD
+	Trailer   metadata.MD
+}
+
+// Call is a call received by a fake.
+type Call struct {
+	// Method is the method name, e.g. "SayHello".
+	Method   string
+	Metadata metadata.MD
+}
+
+// fake holds the behaviors and the received calls of a fake service.
+type fake struct {
+	mu        sync.Mutex
+	behaviors map[string]Behavior
+	calls     []Call
+}
+
+// On sets the behavior of the calls of method made from now on.
+func (f *fake) On(method string, b Behavior) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.behaviors == nil {
+		f.behaviors = map[string]Behavior{}
+	}
+
+	f.behaviors[method] = b
+}
+
+// Calls returns the calls received for method, in arrival order.
+func (f *fake) Calls(method string) []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var calls []Call
+	for _, c := range f.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+
+	return calls
+}
+
+// begin records a call of method, sets its header and trailer and waits for
+// its delay. It returns the behavior of the call, or the context error when
+// the wait was cut short.
+func (f *fake) begin(ctx context.Context, method string) (Behavior, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	f.mu.Lock()
+	b := f.behaviors[method]
+	f.calls = append(f.calls, Call{Method: method, Metadata: md})
+	f.mu.Unlock()
+
+	if b.Header != nil {
+		grpc.SetHeader(ctx, b.Header)
+	}
+
+	if b.Trailer != nil {
+		grpc.SetTrailer(ctx, b.Trailer)
+	}
+
+	if b.Delay > 0 {
+		timer := time.NewTimer(b.Delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return b, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+
+	return b, nil
+}
+
+// Server is a running fake server, use On on its services to script them.
+type Server struct {
+	Hello                  *Hello
+	Bank                   *Bank
+	Resiliency             *Resiliency
+	ResiliencyWithMetadata *ResiliencyWithMetadata
+}
+
+// Start serves the fake services on a bufconn listener and dials it with
+// opts, on top of the bufconn dialer and insecure credentials. The server
+// and the connection are stopped when the test ends.
+func Start(t testing.TB, opts ...grpc.DialOption) (*Server, *grpc.ClientConn) {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	srv := &Server{
+		Hello:                  &Hello{},
+		Bank:                   &Bank{},
+		Resiliency:             &Resiliency{},
+		ResiliencyWithMetadata: &ResiliencyWithMetadata{},
+	}
+
+	s := grpc.NewServer()
+	hello.RegisterHelloServiceServer(s, srv.Hello)
+	bank.RegisterBankServiceServer(s, srv.Bank)
+	resl.RegisterResiliencyServiceServer(s, srv.Resiliency)
+	resl.RegisterResiliencyWithMetadataServiceServer(s, srv.ResiliencyWithMetadata)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	opts = append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, opts...)
+
+	conn, err := grpc.Dial("bufnet", opts...)
+	if err != nil {
+		t.Fatalf("dial bufnet: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return srv, conn
+}
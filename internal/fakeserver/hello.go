@@ -0,0 +1,104 @@
+package fakeserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/hello"
+)
+
+// Hello is a fake hello.HelloService greeting with "Hello <name>".
+type Hello struct {
+	hello.UnimplementedHelloServiceServer
+	fake
+}
+
+func (h *Hello) SayHello(ctx context.Context, req *hello.HelloRequest) (*hello.HelloResponse, error) {
+	b, err := h.begin(ctx, "SayHello")
+	if err != nil {
+		return nil, err
+	}
+
+	if b.Err != nil {
+		return nil, b.Err
+	}
+
+	return &hello.HelloResponse{Greet: "Hello " + req.Name}, nil
+}
+
+// SayManyHello sends StreamLength greetings numbered from 1.
+func (h *Hello) SayManyHello(req *hello.HelloRequest, stream hello.HelloService_SayManyHelloServer) error {
+	b, err := h.begin(stream.Context(), "SayManyHello")
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < StreamLength; i++ {
+		if b.Err != nil && i == b.FailAfter {
+			return b.Err
+		}
+
+		if err := stream.Send(&hello.HelloResponse{Greet: fmt.Sprintf("Hello %v %v", req.Name, i+1)}); err != nil {
+			return err
+		}
+	}
+
+	return b.Err
+}
+
+// SayHelloToEveryone greets every received name at once, e.g. "Hello a, b".
+func (h *Hello) SayHelloToEveryone(stream hello.HelloService_SayHelloToEveryoneServer) error {
+	b, err := h.begin(stream.Context(), "SayHelloToEveryone")
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		names = append(names, req.Name)
+	}
+
+	if b.Err != nil {
+		return b.Err
+	}
+
+	return stream.SendAndClose(&hello.HelloResponse{Greet: "Hello " + strings.Join(names, ", ")})
+}
+
+// SayHelloContinuous greets every received name as it arrives.
+func (h *Hello) SayHelloContinuous(stream hello.HelloService_SayHelloContinuousServer) error {
+	b, err := h.begin(stream.Context(), "SayHelloContinuous")
+	if err != nil {
+		return err
+	}
+
+	for i := 0; ; i++ {
+		if b.Err != nil && i == b.FailAfter {
+			return b.Err
+		}
+
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := stream.Send(&hello.HelloResponse{Greet: "Hello " + req.Name}); err != nil {
+			return err
+		}
+	}
+}
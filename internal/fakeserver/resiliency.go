@@ -0,0 +1,205 @@
+package fakeserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	resl "github.com/fbriansyah/my-grpc-proto/protogen/go/resiliency"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// resiliencyFake answers the resiliency requests the way the real service
+// does, except that the delays of the requests are ignored, use
+// Behavior.Delay instead, and that the status codes are not picked at random:
+// the k-th answer of a method, counted over all its calls, has the status
+// code StatusCodes[k % len(StatusCodes)] of its request, so a request with
+// codes {UNAVAILABLE, OK} fails once and then succeeds.
+type resiliencyFake struct {
+	fake
+
+	answers map[string]int
+}
+
+func (r *resiliencyFake) answer(method string, req *resl.ResiliencyRequest) (*resl.ResiliencyResponse, error) {
+	r.mu.Lock()
+	if r.answers == nil {
+		r.answers = map[string]int{}
+	}
+	k := r.answers[method]
+	r.answers[method]++
+	r.mu.Unlock()
+
+	code := codes.OK
+	if n := len(req.StatusCodes); n > 0 {
+		code = codes.Code(req.StatusCodes[k%n])
+	}
+
+	if code != codes.OK {
+		return nil, status.Errorf(code, "%v answer %v failed with %v", method, k, code)
+	}
+
+	return &resl.ResiliencyResponse{DummyString: fmt.Sprintf("%v answer %v", method, k)}, nil
+}
+
+func (r *resiliencyFake) unary(ctx context.Context, method string, req *resl.ResiliencyRequest) (*resl.ResiliencyResponse, error) {
+	b, err := r.begin(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.Err != nil {
+		return nil, b.Err
+	}
+
+	return r.answer(method, req)
+}
+
+// serverStreaming sends StreamLength answers, ending at the first failed one.
+func (r *resiliencyFake) serverStreaming(method string, req *resl.ResiliencyRequest, stream grpc.ServerStream) error {
+	b, err := r.begin(stream.Context(), method)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < StreamLength; i++ {
+		if b.Err != nil && i == b.FailAfter {
+			return b.Err
+		}
+
+		res, err := r.answer(method, req)
+		if err != nil {
+			return err
+		}
+
+		if err := stream.SendMsg(res); err != nil {
+			return err
+		}
+	}
+
+	return b.Err
+}
+
+// clientStreaming answers once all the requests are received, using the
+// last one.
+func (r *resiliencyFake) clientStreaming(method string, stream grpc.ServerStream) error {
+	b, err := r.begin(stream.Context(), method)
+	if err != nil {
+		return err
+	}
+
+	last := &resl.ResiliencyRequest{}
+	for {
+		req := &resl.ResiliencyRequest{}
+
+		err := stream.RecvMsg(req)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		last = req
+	}
+
+	if b.Err != nil {
+		return b.Err
+	}
+
+	res, err := r.answer(method, last)
+	if err != nil {
+		return err
+	}
+
+	return stream.SendMsg(res)
+}
+
+// biDirectional answers every request as it arrives, ending at the first
+// failed answer.
+func (r *resiliencyFake) biDirectional(method string, stream grpc.ServerStream) error {
+	b, err := r.begin(stream.Context(), method)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; ; i++ {
+		if b.Err != nil && i == b.FailAfter {
+			return b.Err
+		}
+
+		req := &resl.ResiliencyRequest{}
+
+		err := stream.RecvMsg(req)
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		res, err := r.answer(method, req)
+		if err != nil {
+			return err
+		}
+
+		if err := stream.SendMsg(res); err != nil {
+			return err
+		}
+	}
+}
+
+// Resiliency is a fake resl.ResiliencyService, see resiliencyFake for how
+// it answers.
+type Resiliency struct {
+	resl.UnimplementedResiliencyServiceServer
+	resiliencyFake
+}
+
+func (r *Resiliency) UnaryResiliency(ctx context.Context, req *resl.ResiliencyRequest) (*resl.ResiliencyResponse, error) {
+	return r.unary(ctx, "UnaryResiliency", req)
+}
+
+func (r *Resiliency) ServerStreamingResiliency(req *resl.ResiliencyRequest,
+	stream resl.ResiliencyService_ServerStreamingResiliencyServer) error {
+	return r.serverStreaming("ServerStreamingResiliency", req, stream)
+}
+
+func (r *Resiliency) ClientStreamingResiliency(stream resl.ResiliencyService_ClientStreamingResiliencyServer) error {
+	return r.clientStreaming("ClientStreamingResiliency", stream)
+}
+
+func (r *Resiliency) BiDirectionalResiliency(stream resl.ResiliencyService_BiDirectionalResiliencyServer) error {
+	return r.biDirectional("BiDirectionalResiliency", stream)
+}
+
+// ResiliencyWithMetadata is a fake resl.ResiliencyWithMetadataService, see
+// resiliencyFake for how it answers.
+type ResiliencyWithMetadata struct {
+	resl.UnimplementedResiliencyWithMetadataServiceServer
+	resiliencyFake
+}
+
+func (r *ResiliencyWithMetadata) UnaryResiliencyWithMetadata(ctx context.Context,
+	req *resl.ResiliencyRequest) (*resl.ResiliencyResponse, error) {
+	return r.unary(ctx, "UnaryResiliencyWithMetadata", req)
+}
+
+func (r *ResiliencyWithMetadata) ServerStreamingResiliencyWithMetadata(req *resl.ResiliencyRequest,
+	stream resl.ResiliencyWithMetadataService_ServerStreamingResiliencyWithMetadataServer) error {
+	return r.serverStreaming("ServerStreamingResiliencyWithMetadata", req, stream)
+}
+
+func (r *ResiliencyWithMetadata) ClientStreamingResiliencyWithMetadata(
+	stream resl.ResiliencyWithMetadataService_ClientStreamingResiliencyWithMetadataServer) error {
+	return r.clientStreaming("ClientStreamingResiliencyWithMetadata", stream)
+}
+
+func (r *ResiliencyWithMetadata) BiDirectionalResiliencyWithMetadata(
+	stream resl.ResiliencyWithMetadataService_BiDirectionalResiliencyWithMetadataServer) error {
+	return r.biDirectional("BiDirectionalResiliencyWithMetadata", stream)
+}
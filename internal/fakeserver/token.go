@@ -0,0 +1,128 @@
+package fakeserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TokenBehavior scripts how the fake token endpoint answers.
+type TokenBehavior struct {
+	// FailWith are the status codes of the next responses, one per request,
+	// before the endpoint grants tokens again.
+	FailWith []int
+	// ExpiresIn is the lifetime of the granted tokens, an hour when 0.
+	ExpiresIn time.Duration
+}
+
+// TokenServer is a fake OAuth2 token endpoint granting the client
+// credentials of one client, on an httptest server. The tokens granted are
+// "token-1", "token-2" and so on.
+type TokenServer struct {
+	// URL is the token endpoint.
+	URL          string
+	ClientID     string
+	ClientSecret string
+
+	mu       sync.Mutex
+	behavior TokenBehavior
+	requests []url.Values
+	granted  int
+}
+
+// StartTokenServer serves a token endpoint for clientID and clientSecret,
+// stopped when the test ends.
+func StartTokenServer(t testing.TB, clientID, clientSecret string) *TokenServer {
+	t.Helper()
+
+	s := &TokenServer{ClientID: clientID, ClientSecret: clientSecret}
+
+	srv := httptest.NewServer(http.HandlerFunc(s.serveToken))
+	t.Cleanup(srv.Close)
+	s.URL = srv.URL + "/oauth2/token"
+
+	return s
+}
+
+// On sets the behavior of the requests made from now on.
+func (s *TokenServer) On(b TokenBehavior) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.behavior = b
+}
+
+// Requests returns the forms of the requests received, in arrival order.
+func (s *TokenServer) Requests() []url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]url.Values(nil), s.requests...)
+}
+
+func (s *TokenServer) serveToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/oauth2/token" {
+		http.NotFound(w, r)
+		return
+	}
+
+	r.ParseForm()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, r.PostForm)
+
+	if len(s.behavior.FailWith) > 0 {
+		code := s.behavior.FailWith[0]
+		s.behavior.FailWith = s.behavior.FailWith[1:]
+		writeTokenError(w, code, "temporarily_unavailable")
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != "client_credentials" {
+		writeTokenError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	// the client credentials are form-urlencoded before basic
+	// authentication, RFC 6749 section 2.3.1
+	id, secret, ok := r.BasicAuth()
+	if ok {
+		var idErr, secretErr error
+		id, idErr = url.QueryUnescape(id)
+		secret, secretErr = url.QueryUnescape(secret)
+		ok = idErr == nil && secretErr == nil
+	}
+
+	if !ok || id != s.ClientID || secret != s.ClientSecret {
+		writeTokenError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	expiresIn := s.behavior.ExpiresIn
+	if expiresIn == 0 {
+		expiresIn = time.Hour
+	}
+
+	s.granted++
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": fmt.Sprintf("token-%d", s.granted),
+		"token_type":   "Bearer",
+		"expires_in":   int(expiresIn.Seconds()),
+		"scope":        r.PostForm.Get("scope"),
+	})
+}
+
+func writeTokenError(w http.ResponseWriter, code int, oauthErr string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": oauthErr})
+}
@@ -0,0 +1,262 @@
+package interceptor
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BreakerPolicy decides when a circuit breaker trips and recovers.
+type BreakerPolicy struct {
+	// MinRequests is the number of requests seen before the breaker may trip.
+	MinRequests uint32
+	// FailureRatio trips the breaker once failures/requests reaches it.
+	FailureRatio float64
+	// Timeout is how long the breaker stays open before going half-open.
+	Timeout time.Duration
+	// MaxRequests is the number of requests let through while half-open.
+	MaxRequests uint32
+	// Interval clears the counts while closed, 0 never clears them.
+	Interval time.Duration
+	// FailureCodes are the status codes counted as failures, every error
+	// counts when it is empty.
+	FailureCodes []codes.Code
+}
+
+func (p BreakerPolicy) failed(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if len(p.FailureCodes) == 0 {
+		return true
+	}
+
+	code := status.Code(err)
+	for _, c := range p.FailureCodes {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BreakerKey returns the breaker a call goes through.
+type BreakerKey func(method string, cc *grpc.ClientConn) string
+
+// BreakerPerMethod keeps a breaker per full method name.
+func BreakerPerMethod(method string, cc *grpc.ClientConn) string {
+	return method
+}
+
+// BreakerPerTarget keeps a breaker per server address.
+func BreakerPerTarget(method string, cc *grpc.ClientConn) string {
+	return cc.Target()
+}
+
+// Breakers holds an independent circuit breaker per key, created on the
+// first call that needs it.
+type Breakers struct {
+	policy func(key string) BreakerPolicy
+	key    BreakerKey
+
+	mu            sync.Mutex
+	breakers      map[string]*breaker
+	onStateChange []func(name string, from, to gobreaker.State)
+}
+
+type breaker struct {
+	*gobreaker.TwoStepCircuitBreaker
+	policy BreakerPolicy
+}
+
+// NewBreakers returns breakers keyed by key, each with the policy returned
+// for its key.
+func NewBreakers(key BreakerKey, policy func(key string) BreakerPolicy) *Breakers {
+	return &Breakers{
+		policy:   policy,
+		key:      key,
+		breakers: map[string]*breaker{},
+	}
+}
+
+func (b *Breakers) get(key string) *breaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if cb, ok := b.breakers[key]; ok {
+		return cb
+	}
+
+	p := b.policy(key)
+	onStateChange := b.onStateChange
+	cb := &breaker{
+		policy: p,
+		TwoStepCircuitBreaker: gobreaker.NewTwoStepCircuitBreaker(gobreaker.Settings{
+			Name:        key,
+			MaxRequests: p.MaxRequests,
+			Interval:    p.Interval,
+			Timeout:     p.Timeout,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				failureRatio := float64(counts.TotalFailures) / float64(counts.Requests)
+
+				return counts.Requests >= p.MinRequests && failureRatio >= p.FailureRatio
+			},
+			OnStateChange: func(name string, from, to gobreaker.State) {
+				for _, f := range onStateChange {
+					f(name, from, to)
+				}
+			},
+		}),
+	}
+	b.breakers[key] = cb
+
+	return cb
+}
+
+// OnStateChange calls f every time a breaker changes state, after the
+// functions added before, e.g. Metrics.BreakerStateChange or
+// LogBreakerStateChange. It applies to the breakers created after it is
+// called, so it must be called before the first call.
+func (b *Breakers) OnStateChange(f func(name string, from, to gobreaker.State)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.onStateChange = append(b.onStateChange, f)
+}
+
+// LogBreakerStateChange returns a function logging the state changes of the
+// breakers on logger, or slog.Default() when nil, meant for
+// Breakers.OnStateChange. Opening is a warning, the other changes are info.
+func LogBreakerStateChange(logger *slog.Logger) func(name string, from, to gobreaker.State) {
+	return func(name string, from, to gobreaker.State) {
+		l := logger
+		if l == nil {
+			l = slog.Default()
+		}
+
+		level := slog.LevelInfo
+		if to == gobreaker.StateOpen {
+			level = slog.LevelWarn
+		}
+
+		l.LogAttrs(context.Background(), level, "grpc circuit breaker",
+			slog.String("breaker", name),
+			slog.String("from", from.String()),
+			slog.String("to", to.String()),
+		)
+	}
+}
+
+// State returns the state of the breaker of key, closed when no call went
+// through it yet.
+func (b *Breakers) State(key string) gobreaker.State {
+	b.mu.Lock()
+	cb, ok := b.breakers[key]
+	b.mu.Unlock()
+
+	if !ok {
+		return gobreaker.StateClosed
+	}
+
+	return cb.State()
+}
+
+// Keys returns the keys of the breakers created so far, sorted.
+func (b *Breakers) Keys() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	keys := make([]string, 0, len(b.breakers))
+	for key := range b.breakers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// allow asks the breaker of the call for permission. A rejection is
+// reported as codes.Unavailable, the breaker standing in for the backend.
+func (b *Breakers) allow(method string, cc *grpc.ClientConn) (*breaker, func(success bool), error) {
+	key := b.key(method, cc)
+	cb := b.get(key)
+
+	done, err := cb.Allow()
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Unavailable, "circuit breaker %v rejected %v: %v", key, method, err)
+	}
+
+	return cb, done, nil
+}
+
+// BreakerUnaryClientInterceptor sends unary calls through their breaker,
+// failing fast while it is open.
+func BreakerUnaryClientInterceptor(breakers *Breakers) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		cb, done, err := breakers.allow(method, cc)
+		if err != nil {
+			return err
+		}
+
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		done(!cb.policy.failed(err))
+
+		return err
+	}
+}
+
+// BreakerStreamClientInterceptor sends streams through their breaker, the
+// way BreakerUnaryClientInterceptor does for unary calls. A stream counts
+// once: as a failure when it cannot be opened, and otherwise with the error
+// it ends with, seen by RecvMsg. A stream the caller stops reading counts
+// when its context is done, a cancellation counting as a success since the
+// backend is not to blame.
+func BreakerStreamClientInterceptor(breakers *Breakers) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cb, done, err := breakers.allow(method, cc)
+		if err != nil {
+			return nil, err
+		}
+
+		clientStream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			done(!cb.policy.failed(err))
+			return nil, err
+		}
+
+		end := watchStreamEnd(ctx, desc, func(err error) {
+			if ctx.Err() == context.Canceled {
+				// the caller gave up, the backend is not to blame
+				err = nil
+			}
+
+			done(!cb.policy.failed(err))
+		})
+
+		return &breakerClientStream{ClientStream: clientStream, end: end}, nil
+	}
+}
+
+// breakerClientStream reports the outcome of a stream to its breaker once.
+type breakerClientStream struct {
+	grpc.ClientStream
+	end *streamEnd
+}
+
+func (s *breakerClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	s.end.recv(err)
+
+	return err
+}
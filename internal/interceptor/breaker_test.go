@@ -0,0 +1,246 @@
+package interceptor
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	dresl "github.com/fbriansyah/my-grpc-go-client/internal/application/domain/resiliency"
+	"github.com/fbriansyah/my-grpc-go-client/internal/fakeserver"
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/hello"
+	resl "github.com/fbriansyah/my-grpc-proto/protogen/go/resiliency"
+	"github.com/sony/gobreaker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	unaryMethod  = "/resiliency.ResiliencyService/UnaryResiliency"
+	serverMethod = "/resiliency.ResiliencyService/ServerStreamingResiliency"
+	clientMethod = "/resiliency.ResiliencyService/ClientStreamingResiliency"
+)
+
+func newBreakerTestClient(t *testing.T, p BreakerPolicy) (*fakeserver.Server, *grpc.ClientConn, *Breakers) {
+	t.Helper()
+
+	breakers := NewBreakers(BreakerPerMethod, func(string) BreakerPolicy { return p })
+
+	srv, conn := fakeserver.Start(t,
+		grpc.WithChainUnaryInterceptor(BreakerUnaryClientInterceptor(breakers)),
+		grpc.WithChainStreamInterceptor(BreakerStreamClientInterceptor(breakers)),
+	)
+
+	return srv, conn, breakers
+}
+
+func tripAfterTwo() BreakerPolicy {
+	return BreakerPolicy{
+		MinRequests:  2,
+		FailureRatio: 1,
+		Timeout:      50 * time.Millisecond,
+		MaxRequests:  1,
+	}
+}
+
+func TestBreakerUnaryTripsAndRecovers(t *testing.T) {
+	srv, conn, breakers := newBreakerTestClient(t, tripAfterTwo())
+	client := resl.NewResiliencyServiceClient(conn)
+	ctx := context.Background()
+
+	failing := &resl.ResiliencyRequest{StatusCodes: []uint32{unavailable}}
+	for i := 0; i < 2; i++ {
+		client.UnaryResiliency(ctx, failing)
+	}
+
+	if got := breakers.State(unaryMethod); got != gobreaker.StateOpen {
+		t.Fatalf("state = %v, want %v", got, gobreaker.StateOpen)
+	}
+
+	_, err := client.UnaryResiliency(ctx, &resl.ResiliencyRequest{})
+	if got := status.Code(err); got != codes.Unavailable {
+		t.Errorf("rejected call code = %v, want %v", got, codes.Unavailable)
+	}
+
+	if got := len(srv.Resiliency.Calls("UnaryResiliency")); got != 2 {
+		t.Errorf("server got %d calls, want the rejected one to fail fast", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if _, err := client.UnaryResiliency(ctx, &resl.ResiliencyRequest{}); err != nil {
+		t.Fatalf("half-open call: %v", err)
+	}
+
+	if got := breakers.State(unaryMethod); got != gobreaker.StateClosed {
+		t.Errorf("state = %v, want %v", got, gobreaker.StateClosed)
+	}
+}
+
+func TestBreakerStateChangeLogged(t *testing.T) {
+	logs := &logBuffer{}
+	breakers := NewBreakers(BreakerPerMethod, func(string) BreakerPolicy { return tripAfterTwo() })
+	breakers.OnStateChange(LogBreakerStateChange(slog.New(slog.NewJSONHandler(logs, nil))))
+
+	var changes []gobreaker.State
+	breakers.OnStateChange(func(name string, from, to gobreaker.State) { changes = append(changes, to) })
+
+	_, conn := fakeserver.Start(t, grpc.WithChainUnaryInterceptor(BreakerUnaryClientInterceptor(breakers)))
+	client := resl.NewResiliencyServiceClient(conn)
+
+	failing := &resl.ResiliencyRequest{StatusCodes: []uint32{unavailable}}
+	for i := 0; i < 3; i++ {
+		client.UnaryResiliency(context.Background(), failing)
+	}
+
+	// one record for the change, none for the failed calls
+	records := logs.records(t)
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+
+	r := records[0]
+	if r["msg"] != "grpc circuit breaker" || r["breaker"] != unaryMethod || r["from"] != "closed" ||
+		r["to"] != "open" || r["level"] != "WARN" {
+		t.Errorf("state change record = %v", r)
+	}
+
+	if len(changes) != 1 || changes[0] != gobreaker.StateOpen {
+		t.Errorf("second hook saw %v, want [open]", changes)
+	}
+}
+
+func TestBreakerPerMethod(t *testing.T) {
+	_, conn, breakers := newBreakerTestClient(t, tripAfterTwo())
+	client := resl.NewResiliencyServiceClient(conn)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		client.UnaryResiliency(ctx, &resl.ResiliencyRequest{StatusCodes: []uint32{unavailable}})
+	}
+
+	if _, err := hello.NewHelloServiceClient(conn).SayHello(ctx, &hello.HelloRequest{Name: "Budi"}); err != nil {
+		t.Errorf("other method was rejected: %v", err)
+	}
+
+	want := []string{"/hello.HelloService/SayHello", unaryMethod}
+	if got := breakers.Keys(); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("keys = %v, want %v", got, want)
+	}
+}
+
+func TestBreakerFailureCodes(t *testing.T) {
+	p := tripAfterTwo()
+	p.FailureCodes = []codes.Code{codes.Unavailable}
+	_, conn, breakers := newBreakerTestClient(t, p)
+	client := resl.NewResiliencyServiceClient(conn)
+
+	for i := 0; i < 3; i++ {
+		client.UnaryResiliency(context.Background(), &resl.ResiliencyRequest{StatusCodes: []uint32{dresl.NOT_FOUND}})
+	}
+
+	if got := breakers.State(unaryMethod); got != gobreaker.StateClosed {
+		t.Errorf("state = %v, want %v for errors that are not failures", got, gobreaker.StateClosed)
+	}
+}
+
+func TestBreakerServerStreamTripsOnTerminalError(t *testing.T) {
+	srv, conn, breakers := newBreakerTestClient(t, tripAfterTwo())
+	client := resl.NewResiliencyServiceClient(conn)
+	ctx := context.Background()
+
+	// the streams are opened fine and fail after their first message
+	srv.Resiliency.On("ServerStreamingResiliency", fakeserver.Behavior{
+		Err:       status.Error(codes.Internal, "going away"),
+		FailAfter: 1,
+	})
+
+	for i := 0; i < 2; i++ {
+		stream, err := client.ServerStreamingResiliency(ctx, &resl.ResiliencyRequest{})
+		if err != nil {
+			t.Fatalf("ServerStreamingResiliency: %v", err)
+		}
+
+		if _, err := recvAll(t, stream); status.Code(err) != codes.Internal {
+			t.Fatalf("stream ended with %v, want %v", err, codes.Internal)
+		}
+	}
+
+	if got := breakers.State(serverMethod); got != gobreaker.StateOpen {
+		t.Fatalf("state = %v, want %v", got, gobreaker.StateOpen)
+	}
+
+	_, err := client.ServerStreamingResiliency(ctx, &resl.ResiliencyRequest{})
+	if got := status.Code(err); got != codes.Unavailable {
+		t.Errorf("rejected stream code = %v, want %v", got, codes.Unavailable)
+	}
+
+	if got := len(srv.Resiliency.Calls("ServerStreamingResiliency")); got != 2 {
+		t.Errorf("server got %d calls, want the rejected one to fail fast", got)
+	}
+}
+
+func TestBreakerStreamSuccess(t *testing.T) {
+	_, conn, breakers := newBreakerTestClient(t, tripAfterTwo())
+	client := resl.NewResiliencyServiceClient(conn)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		stream, err := client.ServerStreamingResiliency(ctx, &resl.ResiliencyRequest{})
+		if err != nil {
+			t.Fatalf("ServerStreamingResiliency: %v", err)
+		}
+
+		if _, err := recvAll(t, stream); err != nil {
+			t.Fatalf("server stream: %v", err)
+		}
+
+		cstream, err := client.ClientStreamingResiliency(ctx)
+		if err != nil {
+			t.Fatalf("ClientStreamingResiliency: %v", err)
+		}
+
+		if err := cstream.Send(&resl.ResiliencyRequest{}); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+
+		if _, err := cstream.CloseAndRecv(); err != nil {
+			t.Fatalf("client stream: %v", err)
+		}
+	}
+
+	for _, method := range []string{serverMethod, clientMethod} {
+		if got := breakers.State(method); got != gobreaker.StateClosed {
+			t.Errorf("%v state = %v, want %v", method, got, gobreaker.StateClosed)
+		}
+	}
+}
+
+func TestBreakerStreamCancelIsNotFailure(t *testing.T) {
+	srv, conn, breakers := newBreakerTestClient(t, tripAfterTwo())
+	client := resl.NewResiliencyServiceClient(conn)
+	srv.Resiliency.On("ServerStreamingResiliency", fakeserver.Behavior{Delay: time.Second})
+
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		if _, err := client.ServerStreamingResiliency(ctx, &resl.ResiliencyRequest{}); err != nil {
+			t.Fatalf("ServerStreamingResiliency: %v", err)
+		}
+
+		// the caller walks away without reading
+		cancel()
+	}
+
+	// the first streams are counted once the cancellation is seen
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := client.ServerStreamingResiliency(context.Background(), &resl.ResiliencyRequest{}); err != nil {
+		t.Fatalf("stream after cancelled ones: %v", err)
+	}
+
+	if got := breakers.State(serverMethod); got != gobreaker.StateClosed {
+		t.Errorf("state = %v, want %v", got, gobreaker.StateClosed)
+	}
+}
@@ -0,0 +1,244 @@
+package interceptor
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// LogOptions configures the logging interceptors.
+type LogOptions struct {
+	// Logger receives the records, slog.Default() when nil.
+	Logger *slog.Logger
+	// Payloads adds the request and response to the record of a unary call
+	// and logs every stream message, at info level like the calls.
+	Payloads bool
+	// Redact returns the message logged in place of msg, to hide sensitive
+	// fields. It must not modify msg. Messages are logged as they are when
+	// nil.
+	Redact func(msg proto.Message) proto.Message
+}
+
+func (o LogOptions) logger() *slog.Logger {
+	if o.Logger == nil {
+		return slog.Default()
+	}
+
+	return o.Logger
+}
+
+// payload is a message logged as JSON, redacted and marshalled only when
+// the record is written.
+type payload struct {
+	msg    interface{}
+	redact func(proto.Message) proto.Message
+}
+
+func (p payload) LogValue() slog.Value {
+	m, ok := p.msg.(proto.Message)
+	if !ok {
+		return slog.AnyValue(p.msg)
+	}
+
+	if p.redact != nil {
+		m = p.redact(m)
+	}
+
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return slog.AnyValue(err)
+	}
+
+	return slog.StringValue(string(b))
+}
+
+func (o LogOptions) payload(msg interface{}) slog.Value {
+	return slog.AnyValue(payload{msg: msg, redact: o.Redact})
+}
+
+// size returns the encoded size of a proto message, 0 for anything else.
+func size(msg interface{}) int {
+	if m, ok := msg.(proto.Message); ok {
+		return proto.Size(m)
+	}
+
+	return 0
+}
+
+func peerAddr(p *peer.Peer) string {
+	if p == nil || p.Addr == nil {
+		return ""
+	}
+
+	return p.Addr.String()
+}
+
+// codeLevel returns the level a call ending with code is logged at: errors
+// on the server side, or unknown ones, are errors, the ones a retry or the
+// caller may fix are warnings.
+func codeLevel(code codes.Code) slog.Level {
+	switch code {
+	case codes.OK, codes.Canceled, codes.InvalidArgument, codes.NotFound,
+		codes.AlreadyExists, codes.Unauthenticated:
+		return slog.LevelInfo
+	case codes.DeadlineExceeded, codes.PermissionDenied, codes.ResourceExhausted,
+		codes.FailedPrecondition, codes.Aborted, codes.OutOfRange, codes.Unavailable:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+func errorAttrs(err error) []slog.Attr {
+	s := status.Convert(err)
+
+	attrs := []slog.Attr{slog.String("code", s.Code().String())}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", s.Message()))
+	}
+
+	return attrs
+}
+
+// LogUnaryClientInterceptor logs every unary call once it is over, with
+// its full method, status code, duration, peer address and message sizes.
+func LogUnaryClientInterceptor(o LogOptions) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		var p peer.Peer
+		start := time.Now()
+
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Peer(&p))...)
+
+		attrs := []slog.Attr{
+			slog.String("method", method),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("peer", peerAddr(&p)),
+			slog.Int("request_size", size(req)),
+		}
+		attrs = append(attrs, errorAttrs(err)...)
+
+		if err == nil {
+			attrs = append(attrs, slog.Int("response_size", size(reply)))
+		}
+
+		if o.Payloads {
+			attrs = append(attrs, slog.Attr{Key: "request", Value: o.payload(req)})
+			if err == nil {
+				attrs = append(attrs, slog.Attr{Key: "response", Value: o.payload(reply)})
+			}
+		}
+
+		o.logger().LogAttrs(ctx, codeLevel(status.Code(err)), "grpc call", attrs...)
+
+		return err
+	}
+}
+
+// LogStreamClientInterceptor logs every stream once it is over, with its
+// full method, status code, duration, peer address and the number and
+// size of the messages sent and received. A stream is over when RecvMsg
+// returns its last message or error, or when its context is done.
+func LogStreamClientInterceptor(o LogOptions) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+
+		clientStream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			attrs := []slog.Attr{
+				slog.String("method", method),
+				slog.Duration("duration", time.Since(start)),
+			}
+			attrs = append(attrs, errorAttrs(err)...)
+
+			o.logger().LogAttrs(ctx, codeLevel(status.Code(err)), "grpc stream failed to start", attrs...)
+
+			return nil, err
+		}
+
+		s := &loggedClientStream{
+			ClientStream: clientStream,
+			opts:         o,
+			ctx:          ctx,
+			method:       method,
+		}
+		s.end = watchStreamEnd(ctx, desc, func(err error) { s.log(start, err) })
+
+		return s, nil
+	}
+}
+
+// loggedClientStream counts the messages of a stream and logs it once.
+type loggedClientStream struct {
+	grpc.ClientStream
+
+	opts   LogOptions
+	ctx    context.Context
+	method string
+	end    *streamEnd
+
+	sent, received         atomic.Int64
+	sentSize, receivedSize atomic.Int64
+}
+
+func (s *loggedClientStream) log(start time.Time, err error) {
+	p, _ := peer.FromContext(s.ClientStream.Context())
+
+	attrs := []slog.Attr{
+		slog.String("method", s.method),
+		slog.Duration("duration", time.Since(start)),
+		slog.String("peer", peerAddr(p)),
+		slog.Int64("sent", s.sent.Load()),
+		slog.Int64("received", s.received.Load()),
+		slog.Int64("sent_size", s.sentSize.Load()),
+		slog.Int64("received_size", s.receivedSize.Load()),
+	}
+	attrs = append(attrs, errorAttrs(err)...)
+
+	s.opts.logger().LogAttrs(s.ctx, codeLevel(status.Code(err)), "grpc stream", attrs...)
+}
+
+func (s *loggedClientStream) logMessage(direction string, msg interface{}) {
+	if !s.opts.Payloads {
+		return
+	}
+
+	s.opts.logger().LogAttrs(s.ctx, slog.LevelInfo, "grpc stream message",
+		slog.String("method", s.method),
+		slog.String("direction", direction),
+		slog.Attr{Key: "message", Value: s.opts.payload(msg)},
+	)
+}
+
+func (s *loggedClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+		s.sentSize.Add(int64(size(m)))
+		s.logMessage("sent", m)
+	}
+
+	return err
+}
+
+func (s *loggedClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil {
+		s.received.Add(1)
+		s.receivedSize.Add(int64(size(m)))
+		s.logMessage("received", m)
+	}
+
+	s.end.recv(err)
+
+	return err
+}
@@ -0,0 +1,187 @@
+package interceptor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	dresl "github.com/fbriansyah/my-grpc-go-client/internal/application/domain/resiliency"
+	"github.com/fbriansyah/my-grpc-go-client/internal/fakeserver"
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/bank"
+	resl "github.com/fbriansyah/my-grpc-proto/protogen/go/resiliency"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// logBuffer collects JSON log records.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) records(t *testing.T) []map[string]interface{} {
+	t.Helper()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var r map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("record %q: %v", line, err)
+		}
+
+		records = append(records, r)
+	}
+
+	return records
+}
+
+func newLogTestClient(t *testing.T, o LogOptions) (*fakeserver.Server, *grpc.ClientConn, *logBuffer) {
+	t.Helper()
+
+	// the handler options of the CLI, records below info are dropped
+	logs := &logBuffer{}
+	o.Logger = slog.New(slog.NewJSONHandler(logs, nil))
+
+	srv, conn := fakeserver.Start(t,
+		grpc.WithChainUnaryInterceptor(LogUnaryClientInterceptor(o)),
+		grpc.WithChainStreamInterceptor(LogStreamClientInterceptor(o)),
+	)
+
+	return srv, conn, logs
+}
+
+func TestLogUnary(t *testing.T) {
+	_, conn, logs := newLogTestClient(t, LogOptions{})
+	client := resl.NewResiliencyServiceClient(conn)
+
+	client.UnaryResiliency(context.Background(), &resl.ResiliencyRequest{})
+	client.UnaryResiliency(context.Background(), &resl.ResiliencyRequest{StatusCodes: []uint32{dresl.UNKNOWN}})
+
+	records := logs.records(t)
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+
+	ok, failed := records[0], records[1]
+
+	if ok["method"] != unaryMethod || ok["code"] != "OK" || ok["level"] != "INFO" {
+		t.Errorf("record of a successful call = %v", ok)
+	}
+
+	if ok["peer"] == "" || ok["response_size"] == nil || ok["duration"] == nil {
+		t.Errorf("record of a successful call misses fields: %v", ok)
+	}
+
+	if failed["code"] != "Unknown" || failed["level"] != "ERROR" || failed["error"] == nil {
+		t.Errorf("record of a failed call = %v", failed)
+	}
+
+	if _, ok := ok["request"]; ok {
+		t.Errorf("payloads logged without LogOptions.Payloads")
+	}
+}
+
+func TestLogStreamCounts(t *testing.T) {
+	_, conn, logs := newLogTestClient(t, LogOptions{})
+
+	stream, err := resl.NewResiliencyServiceClient(conn).ServerStreamingResiliency(context.Background(),
+		&resl.ResiliencyRequest{})
+	if err != nil {
+		t.Fatalf("ServerStreamingResiliency: %v", err)
+	}
+
+	if _, err := recvAll(t, stream); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	records := logs.records(t)
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+
+	r := records[0]
+	if r["msg"] != "grpc stream" || r["code"] != "OK" || r["sent"] != 1.0 || r["received"] != float64(fakeserver.StreamLength) {
+		t.Errorf("stream record = %v", r)
+	}
+}
+
+func TestLogStreamPayloads(t *testing.T) {
+	_, conn, logs := newLogTestClient(t, LogOptions{Payloads: true})
+
+	stream, err := resl.NewResiliencyServiceClient(conn).ServerStreamingResiliency(context.Background(),
+		&resl.ResiliencyRequest{})
+	if err != nil {
+		t.Fatalf("ServerStreamingResiliency: %v", err)
+	}
+
+	if _, err := recvAll(t, stream); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	records := logs.records(t)
+	if want := 1 + fakeserver.StreamLength + 1; len(records) != want {
+		t.Fatalf("got %d records, want the request, %d responses and the stream", len(records), fakeserver.StreamLength)
+	}
+
+	for i, r := range records[:len(records)-1] {
+		direction := "received"
+		if i == 0 {
+			direction = "sent"
+		}
+
+		if r["msg"] != "grpc stream message" || r["direction"] != direction || r["level"] != "INFO" || r["message"] == nil {
+			t.Errorf("record %d = %v, want the %v message at info", i, r, direction)
+		}
+	}
+}
+
+func TestLogPayloadsRedacted(t *testing.T) {
+	redact := func(msg proto.Message) proto.Message {
+		if req, ok := msg.(*bank.CurrentBalanceRequest); ok {
+			req = proto.Clone(req).(*bank.CurrentBalanceRequest)
+			req.AccountNumber = "***"
+			return req
+		}
+
+		return msg
+	}
+
+	_, conn, logs := newLogTestClient(t, LogOptions{Payloads: true, Redact: redact})
+
+	req := &bank.CurrentBalanceRequest{AccountNumber: "7835697001"}
+	if _, err := bank.NewBankServiceClient(conn).GetCurrentBalance(context.Background(), req); err != nil {
+		t.Fatalf("GetCurrentBalance: %v", err)
+	}
+
+	records := logs.records(t)
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+
+	request, _ := records[0]["request"].(string)
+	if strings.Contains(request, "7835697001") || !strings.Contains(request, "***") {
+		t.Errorf("request = %q, want the account number redacted", request)
+	}
+
+	if req.AccountNumber != "7835697001" {
+		t.Errorf("the request sent was modified to %q", req.AccountNumber)
+	}
+}
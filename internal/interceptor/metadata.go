@@ -0,0 +1,101 @@
+package interceptor
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/fbriansyah/my-grpc-go-client/internal/callmeta"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Keys of the metadata added by the dynamic providers.
+const (
+	RequestUUIDKey   = callmeta.RequestUUIDKey
+	ClientTimeKey    = "grpc-client-time"
+	ClientOSKey      = "grpc.client-os"
+	ClientVersionKey = "grpc-client-version"
+)
+
+// MetadataFunc returns the metadata sent with the calls of a full method
+// name.
+type MetadataFunc func(method string) metadata.MD
+
+// MetadataProvider returns metadata to send with a call, it runs once per
+// call.
+type MetadataProvider func(ctx context.Context, method string) metadata.MD
+
+// StaticMetadata sends md with every call.
+func StaticMetadata(md metadata.MD) MetadataProvider {
+	return func(context.Context, string) metadata.MD { return md }
+}
+
+// MethodMetadata sends the metadata of the method, e.g. Policies.Metadata.
+func MethodMetadata(md MetadataFunc) MetadataProvider {
+	return func(_ context.Context, method string) metadata.MD { return md(method) }
+}
+
+// RequestUUID sends a new random UUID with every call, to find it in the
+// server logs.
+func RequestUUID() MetadataProvider {
+	return func(context.Context, string) metadata.MD {
+		return metadata.Pairs(RequestUUIDKey, uuid.NewString())
+	}
+}
+
+// ClientTime sends the time the call was made, in RFC 3339 format.
+func ClientTime() MetadataProvider {
+	return func(context.Context, string) metadata.MD {
+		return metadata.Pairs(ClientTimeKey, time.Now().Format(time.RFC3339Nano))
+	}
+}
+
+// ClientOS sends the operating system the client runs on.
+func ClientOS() MetadataProvider {
+	return StaticMetadata(metadata.Pairs(ClientOSKey, runtime.GOOS))
+}
+
+// ClientVersion sends the build version of the client.
+func ClientVersion(version string) MetadataProvider {
+	return StaticMetadata(metadata.Pairs(ClientVersionKey, version))
+}
+
+// withMetadata adds the metadata of the providers to the outgoing metadata
+// of ctx. A key already set, by the caller or an earlier provider, is left
+// as is. The metadata sent is recorded in the callmeta capture of ctx.
+func withMetadata(ctx context.Context, method string, providers []MetadataProvider) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+
+	for _, provide := range providers {
+		for k, values := range provide(ctx, method) {
+			if len(md.Get(k)) == 0 {
+				md.Append(k, values...)
+			}
+		}
+	}
+
+	callmeta.FromContext(ctx).SetRequest(md)
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// MetadataUnaryClientInterceptor sends the metadata of the providers with
+// every unary call.
+func MetadataUnaryClientInterceptor(providers ...MetadataProvider) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(withMetadata(ctx, method, providers), method, req, reply, cc, opts...)
+	}
+}
+
+// MetadataStreamClientInterceptor sends the metadata of the providers with
+// every stream.
+func MetadataStreamClientInterceptor(providers ...MetadataProvider) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(withMetadata(ctx, method, providers), desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,58 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fbriansyah/my-grpc-go-client/internal/callmeta"
+	"github.com/fbriansyah/my-grpc-go-client/internal/fakeserver"
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/hello"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestMetadataProviders(t *testing.T) {
+	providers := []MetadataProvider{
+		StaticMetadata(metadata.Pairs("x-team", "payments", "x-env", "test")),
+		RequestUUID(),
+		ClientTime(),
+		ClientOS(),
+		ClientVersion("1.2.3"),
+	}
+
+	srv, conn := fakeserver.Start(t,
+		grpc.WithChainUnaryInterceptor(MetadataUnaryClientInterceptor(providers...)),
+	)
+	client := hello.NewHelloServiceClient(conn)
+
+	// a key set by the caller is kept
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-env", "caller")
+	ctx, capture := callmeta.WithCapture(ctx)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.SayHello(ctx, &hello.HelloRequest{Name: "Budi"}); err != nil {
+			t.Fatalf("SayHello: %v", err)
+		}
+	}
+
+	calls := srv.Hello.Calls("SayHello")
+	first, second := calls[0].Metadata, calls[1].Metadata
+
+	for _, key := range []string{"x-team", RequestUUIDKey, ClientTimeKey, ClientOSKey, ClientVersionKey} {
+		if got := first.Get(key); len(got) != 1 || got[0] == "" {
+			t.Errorf("%v = %v, want one value", key, got)
+		}
+	}
+
+	if got := first.Get("x-env"); len(got) != 1 || got[0] != "caller" {
+		t.Errorf("x-env = %v, want the caller value only", got)
+	}
+
+	if first.Get(RequestUUIDKey)[0] == second.Get(RequestUUIDKey)[0] {
+		t.Errorf("both calls sent request UUID %v", first.Get(RequestUUIDKey))
+	}
+
+	if got := capture.Request.Get(RequestUUIDKey); len(got) != 1 || got[0] != second.Get(RequestUUIDKey)[0] {
+		t.Errorf("captured request UUID = %v, want the one of the last call", got)
+	}
+}
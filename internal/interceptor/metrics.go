@@ -0,0 +1,195 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sony/gobreaker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Metrics are the Prometheus metrics of the calls made by the client and of
+// its circuit breakers.
+type Metrics struct {
+	started     *prometheus.CounterVec
+	handled     *prometheus.CounterVec
+	handling    *prometheus.HistogramVec
+	inFlight    *prometheus.GaugeVec
+	msgSent     *prometheus.CounterVec
+	msgReceived *prometheus.CounterVec
+
+	breakerState       *prometheus.GaugeVec
+	breakerTransitions *prometheus.CounterVec
+}
+
+// NewMetrics returns the client metrics, registered with reg.
+func NewMetrics(reg prometheus.Registerer) (*Metrics, error) {
+	labels := []string{"grpc_type", "grpc_service", "grpc_method"}
+
+	m := &Metrics{
+		started: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_client_started_total",
+			Help: "Total number of RPCs started by the client.",
+		}, labels),
+		handled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_client_handled_total",
+			Help: "Total number of RPCs completed by the client, by status code.",
+		}, append(labels, "grpc_code")),
+		handling: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_client_handling_seconds",
+			Help:    "Time from the start of an RPC to its end, in seconds.",
+			Buckets: prometheus.DefBuckets,
+		}, labels),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "grpc_client_in_flight",
+			Help: "Number of RPCs started and not completed yet.",
+		}, labels),
+		msgSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_client_msg_sent_total",
+			Help: "Total number of stream messages sent by the client.",
+		}, labels),
+		msgReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_client_msg_received_total",
+			Help: "Total number of stream messages received by the client.",
+		}, labels),
+		breakerState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "grpc_client_circuit_breaker_state",
+			Help: "State of a circuit breaker: 0 closed, 1 half-open, 2 open.",
+		}, []string{"breaker"}),
+		breakerTransitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_client_circuit_breaker_transitions_total",
+			Help: "Total number of state changes of a circuit breaker.",
+		}, []string{"breaker", "from", "to"}),
+	}
+
+	for _, c := range []prometheus.Collector{
+		m.started, m.handled, m.handling, m.inFlight, m.msgSent, m.msgReceived,
+		m.breakerState, m.breakerTransitions,
+	} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// BreakerStateChange records a state change of a circuit breaker, it is
+// meant for Breakers.OnStateChange.
+func (m *Metrics) BreakerStateChange(name string, from, to gobreaker.State) {
+	m.breakerState.WithLabelValues(name).Set(float64(to))
+	m.breakerTransitions.WithLabelValues(name, from.String(), to.String()).Inc()
+}
+
+// rpcType returns the grpc_type label of a stream.
+func rpcType(desc *grpc.StreamDesc) string {
+	switch {
+	case desc.ClientStreams && desc.ServerStreams:
+		return "bidi_stream"
+	case desc.ClientStreams:
+		return "client_stream"
+	case desc.ServerStreams:
+		return "server_stream"
+	default:
+		return "unary"
+	}
+}
+
+// splitMethod splits a full method name, e.g. "/hello.HelloService/SayHello",
+// into its service and method.
+func splitMethod(method string) (service, name string) {
+	service, name, _ = strings.Cut(strings.TrimPrefix(method, "/"), "/")
+
+	return service, name
+}
+
+// rpcLabels returns the grpc_type, grpc_service and grpc_method labels of a
+// call to the full method name.
+func rpcLabels(typ, method string) []string {
+	service, name := splitMethod(method)
+
+	return []string{typ, service, name}
+}
+
+// start records the start of a call and returns the function recording its
+// end.
+func (m *Metrics) start(labels []string) func(err error) {
+	start := time.Now()
+
+	m.started.WithLabelValues(labels...).Inc()
+	m.inFlight.WithLabelValues(labels...).Inc()
+
+	return func(err error) {
+		m.inFlight.WithLabelValues(labels...).Dec()
+		m.handling.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+		m.handled.WithLabelValues(append(labels, status.Code(err).String())...).Inc()
+	}
+}
+
+// MetricsUnaryClientInterceptor records the metrics of unary calls.
+func MetricsUnaryClientInterceptor(m *Metrics) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		done := m.start(rpcLabels("unary", method))
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		done(err)
+
+		return err
+	}
+}
+
+// MetricsStreamClientInterceptor records the metrics of streams, and the
+// messages sent and received on them. A stream is in flight until it ends,
+// see streamEnd.
+func MetricsStreamClientInterceptor(m *Metrics) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		labels := rpcLabels(rpcType(desc), method)
+		done := m.start(labels)
+
+		clientStream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			done(err)
+			return nil, err
+		}
+
+		return &metricsClientStream{
+			ClientStream: clientStream,
+			sent:         m.msgSent.WithLabelValues(labels...),
+			received:     m.msgReceived.WithLabelValues(labels...),
+			end:          watchStreamEnd(ctx, desc, done),
+		}, nil
+	}
+}
+
+// metricsClientStream counts the messages of a stream.
+type metricsClientStream struct {
+	grpc.ClientStream
+
+	sent, received prometheus.Counter
+	end            *streamEnd
+}
+
+func (s *metricsClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.sent.Inc()
+	}
+
+	return err
+}
+
+func (s *metricsClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil {
+		s.received.Inc()
+	}
+
+	s.end.recv(err)
+
+	return err
+}
@@ -0,0 +1,120 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+
+	dresl "github.com/fbriansyah/my-grpc-go-client/internal/application/domain/resiliency"
+	"github.com/fbriansyah/my-grpc-go-client/internal/fakeserver"
+	resl "github.com/fbriansyah/my-grpc-proto/protogen/go/resiliency"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+)
+
+func newMetricsTestClient(t *testing.T) (*grpc.ClientConn, *Metrics) {
+	t.Helper()
+
+	m, err := NewMetrics(prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("NewMetrics: %v", err)
+	}
+
+	_, conn := fakeserver.Start(t,
+		grpc.WithChainUnaryInterceptor(MetricsUnaryClientInterceptor(m)),
+		grpc.WithChainStreamInterceptor(MetricsStreamClientInterceptor(m)),
+	)
+
+	return conn, m
+}
+
+func TestMetricsUnary(t *testing.T) {
+	conn, m := newMetricsTestClient(t)
+	client := resl.NewResiliencyServiceClient(conn)
+	ctx := context.Background()
+
+	client.UnaryResiliency(ctx, &resl.ResiliencyRequest{StatusCodes: []uint32{dresl.OK}})
+	client.UnaryResiliency(ctx, &resl.ResiliencyRequest{StatusCodes: []uint32{dresl.NOT_FOUND}})
+
+	labels := []string{"unary", "resiliency.ResiliencyService", "UnaryResiliency"}
+
+	if got := testutil.ToFloat64(m.started.WithLabelValues(labels...)); got != 2 {
+		t.Errorf("started = %v, want 2", got)
+	}
+
+	for _, code := range []string{"OK", "NotFound"} {
+		if got := testutil.ToFloat64(m.handled.WithLabelValues(append(labels, code)...)); got != 1 {
+			t.Errorf("handled %v = %v, want 1", code, got)
+		}
+	}
+
+	if got := testutil.ToFloat64(m.inFlight.WithLabelValues(labels...)); got != 0 {
+		t.Errorf("in flight = %v, want 0", got)
+	}
+
+	if got := testutil.CollectAndCount(m.handling); got != 1 {
+		t.Errorf("got %d latency histograms, want 1", got)
+	}
+}
+
+func TestMetricsStreamMessages(t *testing.T) {
+	conn, m := newMetricsTestClient(t)
+
+	stream, err := resl.NewResiliencyServiceClient(conn).ServerStreamingResiliency(context.Background(),
+		&resl.ResiliencyRequest{})
+	if err != nil {
+		t.Fatalf("ServerStreamingResiliency: %v", err)
+	}
+
+	labels := []string{"server_stream", "resiliency.ResiliencyService", "ServerStreamingResiliency"}
+
+	if got := testutil.ToFloat64(m.inFlight.WithLabelValues(labels...)); got != 1 {
+		t.Errorf("in flight while open = %v, want 1", got)
+	}
+
+	if _, err := recvAll(t, stream); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	if got := testutil.ToFloat64(m.inFlight.WithLabelValues(labels...)); got != 0 {
+		t.Errorf("in flight once ended = %v, want 0", got)
+	}
+
+	if got := testutil.ToFloat64(m.msgSent.WithLabelValues(labels...)); got != 1 {
+		t.Errorf("sent = %v, want 1", got)
+	}
+
+	if got := testutil.ToFloat64(m.msgReceived.WithLabelValues(labels...)); got != fakeserver.StreamLength {
+		t.Errorf("received = %v, want %v", got, fakeserver.StreamLength)
+	}
+
+	if got := testutil.ToFloat64(m.handled.WithLabelValues(append(labels, "OK")...)); got != 1 {
+		t.Errorf("handled OK = %v, want 1", got)
+	}
+}
+
+func TestMetricsBreakerStateChange(t *testing.T) {
+	m, err := NewMetrics(prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("NewMetrics: %v", err)
+	}
+
+	breakers := NewBreakers(BreakerPerMethod, func(string) BreakerPolicy { return tripAfterTwo() })
+	breakers.OnStateChange(m.BreakerStateChange)
+
+	_, conn := fakeserver.Start(t, grpc.WithChainUnaryInterceptor(BreakerUnaryClientInterceptor(breakers)))
+	client := resl.NewResiliencyServiceClient(conn)
+
+	failing := &resl.ResiliencyRequest{StatusCodes: []uint32{unavailable}}
+	for i := 0; i < 2; i++ {
+		client.UnaryResiliency(context.Background(), failing)
+	}
+
+	if got := testutil.ToFloat64(m.breakerState.WithLabelValues(unaryMethod)); got != 2 {
+		t.Errorf("state = %v, want 2 (open)", got)
+	}
+
+	if got := testutil.ToFloat64(m.breakerTransitions.WithLabelValues(unaryMethod, "closed", "open")); got != 1 {
+		t.Errorf("closed to open transitions = %v, want 1", got)
+	}
+}
@@ -0,0 +1,98 @@
+package interceptor
+
+import (
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+// Policy is what the interceptors apply to the calls of a method.
+type Policy struct {
+	// Timeout bounds a unary call, its retries and their backoffs
+	// included, 0 for none.
+	Timeout       time.Duration
+	StreamTimeout StreamTimeout
+	Retry         RetryPolicy
+	Breaker       BreakerPolicy
+	// Metadata is appended to the outgoing metadata of every call.
+	Metadata metadata.MD
+	// Credentials authenticate every call, nil sends none.
+	Credentials credentials.PerRPCCredentials
+}
+
+// Policies holds the policy of every call, keyed by full method name like
+// "/bank.BankService/GetCurrentBalance" or by service wildcard like
+// "/bank.BankService/*". A method uses its own policy, else the one of its
+// service, else the default one. Policies are complete, nothing is
+// inherited at lookup.
+type Policies struct {
+	defaultPolicy Policy
+	policies      map[string]Policy
+}
+
+// NewPolicies returns the policies looked up from policies, falling back to
+// defaultPolicy.
+func NewPolicies(defaultPolicy Policy, policies map[string]Policy) *Policies {
+	p := &Policies{
+		defaultPolicy: defaultPolicy,
+		policies:      map[string]Policy{},
+	}
+
+	for pattern, policy := range policies {
+		p.policies[pattern] = policy
+	}
+
+	return p
+}
+
+// ServicePattern returns the service wildcard matching method, e.g.
+// "/bank.BankService/*" for "/bank.BankService/GetCurrentBalance".
+func ServicePattern(method string) string {
+	return method[:strings.LastIndex(method, "/")+1] + "*"
+}
+
+// Lookup returns the policy of method.
+func (p *Policies) Lookup(method string) Policy {
+	if policy, ok := p.policies[method]; ok {
+		return policy
+	}
+
+	if policy, ok := p.policies[ServicePattern(method)]; ok {
+		return policy
+	}
+
+	return p.defaultPolicy
+}
+
+// Timeout is a TimeoutFunc.
+func (p *Policies) Timeout(method string) time.Duration {
+	return p.Lookup(method).Timeout
+}
+
+// StreamTimeout is a StreamTimeoutFunc.
+func (p *Policies) StreamTimeout(method string) StreamTimeout {
+	return p.Lookup(method).StreamTimeout
+}
+
+// Retry is a RetryPolicyFunc.
+func (p *Policies) Retry(method string) RetryPolicy {
+	return p.Lookup(method).Retry
+}
+
+// Breaker returns the breaker policy for NewBreakers. A key that is not a
+// method, as with BreakerPerTarget, gets the default policy.
+func (p *Policies) Breaker(key string) BreakerPolicy {
+	return p.Lookup(key).Breaker
+}
+
+// Metadata is a MetadataFunc.
+func (p *Policies) Metadata(method string) metadata.MD {
+	return p.Lookup(method).Metadata
+}
+
+// Credentials returns the credentials of method, for auth.PerMethod.
+func (p *Policies) Credentials(method string) credentials.PerRPCCredentials {
+	return p.Lookup(method).Credentials
+}
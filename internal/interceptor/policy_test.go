@@ -0,0 +1,81 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fbriansyah/my-grpc-go-client/internal/fakeserver"
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/bank"
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/hello"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestPoliciesLookup(t *testing.T) {
+	policies := NewPolicies(Policy{Timeout: 5 * time.Second}, map[string]Policy{
+		"/bank.BankService/*":                 {Timeout: 2 * time.Second},
+		"/bank.BankService/GetCurrentBalance": {Timeout: 300 * time.Millisecond},
+	})
+
+	tests := []struct {
+		method string
+		want   time.Duration
+	}{
+		{"/bank.BankService/GetCurrentBalance", 300 * time.Millisecond},
+		{"/bank.BankService/FetchExchangeRates", 2 * time.Second},
+		{"/hello.HelloService/SayHello", 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := policies.Timeout(tt.method); got != tt.want {
+			t.Errorf("Timeout(%v) = %v, want %v", tt.method, got, tt.want)
+		}
+	}
+
+	if got := policies.Breaker("localhost:9090"); got.Timeout != 0 {
+		t.Errorf("Breaker of a target = %+v, want the default policy", got)
+	}
+}
+
+func TestMetadataPerService(t *testing.T) {
+	policies := NewPolicies(Policy{}, map[string]Policy{
+		"/bank.BankService/*": {Metadata: metadata.Pairs("x-client-name", "test")},
+	})
+
+	srv, conn := fakeserver.Start(t,
+		grpc.WithChainUnaryInterceptor(MetadataUnaryClientInterceptor(MethodMetadata(policies.Metadata))),
+		grpc.WithChainStreamInterceptor(MetadataStreamClientInterceptor(MethodMetadata(policies.Metadata))),
+	)
+	ctx := context.Background()
+
+	if _, err := bank.NewBankServiceClient(conn).GetCurrentBalance(ctx, &bank.CurrentBalanceRequest{}); err != nil {
+		t.Fatalf("GetCurrentBalance: %v", err)
+	}
+
+	stream, err := bank.NewBankServiceClient(conn).FetchExchangeRates(ctx, &bank.ExchangeRateRequest{})
+	if err != nil {
+		t.Fatalf("FetchExchangeRates: %v", err)
+	}
+
+	for {
+		if _, err := stream.Recv(); err != nil {
+			break
+		}
+	}
+
+	if _, err := hello.NewHelloServiceClient(conn).SayHello(ctx, &hello.HelloRequest{}); err != nil {
+		t.Fatalf("SayHello: %v", err)
+	}
+
+	for _, method := range []string{"GetCurrentBalance", "FetchExchangeRates"} {
+		calls := srv.Bank.Calls(method)
+		if len(calls) != 1 || len(calls[0].Metadata.Get("x-client-name")) != 1 {
+			t.Errorf("%v calls = %+v, want x-client-name sent once", method, calls)
+		}
+	}
+
+	if calls := srv.Hello.Calls("SayHello"); len(calls) != 1 || len(calls[0].Metadata.Get("x-client-name")) != 0 {
+		t.Errorf("SayHello calls = %+v, want no x-client-name", calls)
+	}
+}
@@ -0,0 +1,148 @@
+// Package redact masks sensitive fields of proto messages before they are
+// logged.
+package redact
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	// registers the messages of BankRules
+	_ "github.com/fbriansyah/my-grpc-proto/protogen/go/bank"
+)
+
+// Rules lists the fields masked in each message, by full message name like
+// "bank.TransferRequest" and field name like "from_account_number".
+type Rules map[string][]string
+
+// BankRules hides the account numbers and notes of the bank service.
+var BankRules = Rules{
+	"bank.CurrentBalanceRequest": {"account_number"},
+	"bank.Transaction":           {"account_number", "notes"},
+	"bank.TransactionSummary":    {"account_number"},
+	"bank.TransferRequest":       {"from_account_number", "to_account_number"},
+	"bank.TransferResponse":      {"from_account_number", "to_account_number"},
+}
+
+// Merge returns the rules of r and others, the fields of a message being
+// the union of theirs.
+func (r Rules) Merge(others ...Rules) Rules {
+	merged := Rules{}
+
+	for _, rules := range append([]Rules{r}, others...) {
+		for message, fields := range rules {
+			merged[message] = append(merged[message], fields...)
+		}
+	}
+
+	return merged
+}
+
+// visibleSuffix is the number of trailing characters left visible in a
+// masked string, so a masked account number can still be told apart.
+const visibleSuffix = 4
+
+// Redactor masks the fields named by its rules, in the message itself and
+// in every message nested in it.
+type Redactor struct {
+	fields map[protoreflect.FullName]map[protoreflect.Name]bool
+}
+
+// New returns a Redactor for rules. Every message and field must be known
+// to protoregistry.GlobalTypes, so typos do not leave fields unmasked.
+func New(rules Rules) (*Redactor, error) {
+	r := &Redactor{fields: map[protoreflect.FullName]map[protoreflect.Name]bool{}}
+
+	for message, fields := range rules {
+		mt, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(message))
+		if err != nil {
+			return nil, fmt.Errorf("redact: message %v: %w", message, err)
+		}
+
+		names := map[protoreflect.Name]bool{}
+		for _, field := range fields {
+			if mt.Descriptor().Fields().ByName(protoreflect.Name(field)) == nil {
+				return nil, fmt.Errorf("redact: message %v has no field %v", message, field)
+			}
+
+			names[protoreflect.Name(field)] = true
+		}
+
+		r.fields[mt.Descriptor().FullName()] = names
+	}
+
+	return r, nil
+}
+
+// Redact returns a copy of msg with the fields of the rules masked, or msg
+// itself when none is set. Strings keep their last characters, every other
+// kind of field is cleared. msg is never modified.
+func (r *Redactor) Redact(msg proto.Message) proto.Message {
+	if msg == nil || !r.needed(msg.ProtoReflect()) {
+		return msg
+	}
+
+	clone := proto.Clone(msg)
+	r.mask(clone.ProtoReflect())
+
+	return clone
+}
+
+// needed reports whether m has a set field to mask.
+func (r *Redactor) needed(m protoreflect.Message) bool {
+	found := false
+
+	r.walk(m, func(protoreflect.Message, protoreflect.FieldDescriptor) { found = true })
+
+	return found
+}
+
+func (r *Redactor) mask(m protoreflect.Message) {
+	r.walk(m, func(m protoreflect.Message, fd protoreflect.FieldDescriptor) {
+		if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() {
+			m.Set(fd, protoreflect.ValueOfString(maskString(m.Get(fd).String())))
+			return
+		}
+
+		m.Clear(fd)
+	})
+}
+
+// walk calls f for every set field of m and of its nested messages that
+// the rules mask.
+func (r *Redactor) walk(m protoreflect.Message, f func(protoreflect.Message, protoreflect.FieldDescriptor)) {
+	masked := r.fields[m.Descriptor().FullName()]
+
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case masked[fd.Name()]:
+			f(m, fd)
+		case fd.IsList() && fd.Message() != nil:
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				r.walk(list.Get(i).Message(), f)
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+				r.walk(v.Message(), f)
+				return true
+			})
+		case fd.Message() != nil && !fd.IsMap():
+			r.walk(v.Message(), f)
+		}
+
+		return true
+	})
+}
+
+func maskString(s string) string {
+	runes := []rune(s)
+	if len(runes) <= visibleSuffix {
+		return strings.Repeat("*", len(runes))
+	}
+
+	return strings.Repeat("*", len(runes)-visibleSuffix) + string(runes[len(runes)-visibleSuffix:])
+}
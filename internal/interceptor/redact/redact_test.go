@@ -0,0 +1,76 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/bank"
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/hello"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBankRules(t *testing.T) {
+	r, err := New(BankRules)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	req := &bank.TransferRequest{
+		FromAccountNumber: "7835697001",
+		ToAccountNumber:   "7835697002",
+		Currency:          "USD",
+		Amount:            250,
+	}
+
+	got := r.Redact(req).(*bank.TransferRequest)
+
+	if got.FromAccountNumber != "******7001" || got.ToAccountNumber != "******7002" {
+		t.Errorf("account numbers = %q and %q, want them masked", got.FromAccountNumber, got.ToAccountNumber)
+	}
+
+	if got.Currency != "USD" || got.Amount != 250 {
+		t.Errorf("fields without a rule changed: %v", got)
+	}
+
+	if req.FromAccountNumber != "7835697001" {
+		t.Errorf("the message itself was modified: %v", req)
+	}
+}
+
+func TestRedactUntouched(t *testing.T) {
+	r, err := New(BankRules)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for _, msg := range []proto.Message{
+		&hello.HelloRequest{Name: "Budi"},
+		&bank.CurrentBalanceRequest{},
+	} {
+		if got := r.Redact(msg); got != msg {
+			t.Errorf("Redact(%v) = %v, want the message itself", msg, got)
+		}
+	}
+}
+
+func TestMergedRulesClearOtherKinds(t *testing.T) {
+	r, err := New(BankRules.Merge(Rules{"bank.TransferRequest": {"amount"}}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	got := r.Redact(&bank.TransferRequest{FromAccountNumber: "123", Amount: 250}).(*bank.TransferRequest)
+	if got.FromAccountNumber != "***" || got.Amount != 0 {
+		t.Errorf("Redact = %v, want the account number masked and the amount cleared", got)
+	}
+}
+
+func TestNewRejectsUnknownNames(t *testing.T) {
+	for _, rules := range []Rules{
+		{"bank.NoSuchMessage": {"account_number"}},
+		{"bank.TransferRequest": {"no_such_field"}},
+	} {
+		if _, err := New(rules); err == nil {
+			t.Errorf("New(%v) succeeded, want an error", rules)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package interceptor
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RetryAttemptHeader carries the 1-based attempt number of every call made
+// by the retry interceptors, so servers can tell retries apart.
+const RetryAttemptHeader = "x-retry-attempt"
+
+// RetryPolicy decides whether and how a failed call is tried again.
+type RetryPolicy struct {
+	// MaxAttempts counts the first attempt, 1 or less disables retries.
+	MaxAttempts int
+	// Codes are the status codes worth another attempt.
+	Codes []codes.Code
+	// InitialBackoff is the wait before the second attempt, each following
+	// wait is Multiplier times longer, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter spreads every wait by up to this fraction either way, e.g. 0.2
+	// waits between 80% and 120% of the backoff.
+	Jitter float64
+}
+
+// RetryPolicyFunc returns the policy for a full method name like
+// "/resiliency.ResiliencyService/UnaryResiliency".
+type RetryPolicyFunc func(method string) RetryPolicy
+
+func (p RetryPolicy) retryable(err error) bool {
+	code := status.Code(err)
+
+	for _, c := range p.Codes {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// backoff returns the wait before attempt, 2 or more.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	d := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-2))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		d *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+
+	return time.Duration(d)
+}
+
+// wait sleeps before attempt and reports whether it may be made: not when
+// ctx is done first, nor when its deadline would pass during the wait.
+func (p RetryPolicy) wait(ctx context.Context, attempt int) bool {
+	d := p.backoff(attempt)
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= d {
+		return false
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, RetryAttemptHeader, strconv.Itoa(attempt))
+}
+
+// logRetry records that attempt of method is made after err, on logger or
+// slog.Default() when nil.
+func logRetry(ctx context.Context, logger *slog.Logger, method string, err error, attempt, maxAttempts int) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "grpc retry",
+		slog.String("method", method),
+		slog.String("code", status.Code(err).String()),
+		slog.Int("attempt", attempt),
+		slog.Int("max_attempts", maxAttempts),
+	)
+}
+
+// RetryUnaryClientInterceptor tries unary calls again on the codes of their
+// policy, waiting with exponential backoff in between. It stops early when
+// the context of the call is done or its deadline would pass during the
+// wait, returning the last error. Every retry is logged on logger, or
+// slog.Default() when nil.
+func RetryUnaryClientInterceptor(policy RetryPolicyFunc, logger *slog.Logger) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		p := policy(method)
+
+		for attempt := 1; ; attempt++ {
+			err := invoker(withAttempt(ctx, attempt), method, req, reply, cc, opts...)
+
+			if err == nil || attempt >= p.MaxAttempts || !p.retryable(err) || !p.wait(ctx, attempt+1) {
+				return err
+			}
+
+			logRetry(ctx, logger, method, err, attempt+1, p.MaxAttempts)
+		}
+	}
+}
+
+// RetryStreamClientInterceptor tries server streaming calls again, the way
+// RetryUnaryClientInterceptor does, as long as no response was received:
+// once a message was handed to the caller, a retry would deliver it twice.
+// Client and bidi streams are passed through, their requests cannot be
+// replayed. Every retry is logged on logger, or slog.Default() when nil.
+func RetryStreamClientInterceptor(policy RetryPolicyFunc, logger *slog.Logger) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		p := policy(method)
+
+		if desc.ClientStreams || !desc.ServerStreams || p.MaxAttempts <= 1 {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		s := &retryClientStream{
+			ctx:      ctx,
+			desc:     desc,
+			cc:       cc,
+			method:   method,
+			streamer: streamer,
+			opts:     opts,
+			policy:   p,
+			logger:   logger,
+		}
+
+		for s.attempt = 1; ; s.attempt++ {
+			cs, err := streamer(withAttempt(ctx, s.attempt), desc, cc, method, opts...)
+			if err == nil {
+				s.ClientStream = cs
+				return s, nil
+			}
+
+			if s.attempt >= p.MaxAttempts || !p.retryable(err) || !p.wait(ctx, s.attempt+1) {
+				return nil, err
+			}
+
+			logRetry(ctx, logger, method, err, s.attempt+1, p.MaxAttempts)
+		}
+	}
+}
+
+// retryClientStream replays the single request of a server stream on a new
+// stream when the current one fails before its first response.
+type retryClientStream struct {
+	grpc.ClientStream
+
+	ctx      context.Context
+	desc     *grpc.StreamDesc
+	cc       *grpc.ClientConn
+	method   string
+	streamer grpc.Streamer
+	opts     []grpc.CallOption
+	policy   RetryPolicy
+	logger   *slog.Logger
+
+	attempt    int
+	req        interface{}
+	sendClosed bool
+	received   bool
+}
+
+func (s *retryClientStream) SendMsg(m interface{}) error {
+	s.req = m
+
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *retryClientStream) CloseSend() error {
+	s.sendClosed = true
+
+	return s.ClientStream.CloseSend()
+}
+
+func (s *retryClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+
+	for err != nil && err != io.EOF && !s.received && s.attempt < s.policy.MaxAttempts &&
+		s.policy.retryable(err) && s.policy.wait(s.ctx, s.attempt+1) {
+		s.attempt++
+
+		logRetry(s.ctx, s.logger, s.method, err, s.attempt, s.policy.MaxAttempts)
+
+		if err = s.reopen(); err == nil {
+			err = s.ClientStream.RecvMsg(m)
+		}
+	}
+
+	if err == nil {
+		s.received = true
+	}
+
+	return err
+}
+
+// reopen opens the stream again and replays what was sent on it.
+func (s *retryClientStream) reopen() error {
+	cs, err := s.streamer(withAttempt(s.ctx, s.attempt), s.desc, s.cc, s.method, s.opts...)
+	if err != nil {
+		return err
+	}
+
+	s.ClientStream = cs
+
+	if s.req != nil {
+		if err := cs.SendMsg(s.req); err != nil {
+			return err
+		}
+	}
+
+	if s.sendClosed {
+		return cs.CloseSend()
+	}
+
+	return nil
+}
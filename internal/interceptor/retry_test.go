@@ -0,0 +1,240 @@
+package interceptor
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	dresl "github.com/fbriansyah/my-grpc-go-client/internal/application/domain/resiliency"
+	"github.com/fbriansyah/my-grpc-go-client/internal/fakeserver"
+	resl "github.com/fbriansyah/my-grpc-proto/protogen/go/resiliency"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const unavailable = uint32(codes.Unavailable)
+
+func newRetryTestClient(t *testing.T, p RetryPolicy) (*fakeserver.Server, resl.ResiliencyServiceClient) {
+	t.Helper()
+
+	srv, client, _ := newLoggedRetryTestClient(t, p)
+
+	return srv, client
+}
+
+// newLoggedRetryTestClient is newRetryTestClient keeping the JSON records
+// of the retries.
+func newLoggedRetryTestClient(t *testing.T, p RetryPolicy) (*fakeserver.Server, resl.ResiliencyServiceClient,
+	*logBuffer) {
+	t.Helper()
+
+	policy := func(string) RetryPolicy { return p }
+	logs := &logBuffer{}
+	logger := slog.New(slog.NewJSONHandler(logs, nil))
+
+	srv, conn := fakeserver.Start(t,
+		grpc.WithChainUnaryInterceptor(RetryUnaryClientInterceptor(policy, logger)),
+		grpc.WithChainStreamInterceptor(RetryStreamClientInterceptor(policy, logger)),
+	)
+
+	return srv, resl.NewResiliencyServiceClient(conn), logs
+}
+
+func fastRetries(maxAttempts int) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    maxAttempts,
+		Codes:          []codes.Code{codes.Unavailable},
+		InitialBackoff: time.Millisecond,
+		Multiplier:     2,
+	}
+}
+
+func attempts(calls []fakeserver.Call) []string {
+	var got []string
+	for _, c := range calls {
+		got = append(got, c.Metadata.Get(RetryAttemptHeader)...)
+	}
+
+	return got
+}
+
+func TestRetryUnaryUntilSuccess(t *testing.T) {
+	srv, client := newRetryTestClient(t, fastRetries(3))
+
+	req := &resl.ResiliencyRequest{StatusCodes: []uint32{unavailable, unavailable, dresl.OK}}
+	if _, err := client.UnaryResiliency(context.Background(), req); err != nil {
+		t.Fatalf("UnaryResiliency: %v", err)
+	}
+
+	got := attempts(srv.Resiliency.Calls("UnaryResiliency"))
+	if len(got) != 3 || got[0] != "1" || got[1] != "2" || got[2] != "3" {
+		t.Errorf("attempt headers = %v, want [1 2 3]", got)
+	}
+}
+
+func TestRetryLogged(t *testing.T) {
+	_, client, logs := newLoggedRetryTestClient(t, fastRetries(3))
+
+	req := &resl.ResiliencyRequest{StatusCodes: []uint32{unavailable, dresl.OK}}
+	if _, err := client.UnaryResiliency(context.Background(), req); err != nil {
+		t.Fatalf("UnaryResiliency: %v", err)
+	}
+
+	records := logs.records(t)
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1 per retry", len(records))
+	}
+
+	r := records[0]
+	if r["msg"] != "grpc retry" || r["method"] != unaryMethod || r["code"] != "Unavailable" ||
+		r["attempt"] != 2.0 || r["max_attempts"] != 3.0 {
+		t.Errorf("retry record = %v", r)
+	}
+}
+
+func TestRetryUnaryStops(t *testing.T) {
+	tests := []struct {
+		name     string
+		codes    []uint32
+		wantCode codes.Code
+		wantRuns int
+	}{
+		{"not retryable", []uint32{dresl.NOT_FOUND, dresl.OK}, codes.NotFound, 1},
+		{"attempts exhausted", []uint32{unavailable}, codes.Unavailable, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, client := newRetryTestClient(t, fastRetries(3))
+
+			_, err := client.UnaryResiliency(context.Background(), &resl.ResiliencyRequest{StatusCodes: tt.codes})
+			if got := status.Code(err); got != tt.wantCode {
+				t.Errorf("code = %v, want %v", got, tt.wantCode)
+			}
+
+			if got := len(srv.Resiliency.Calls("UnaryResiliency")); got != tt.wantRuns {
+				t.Errorf("server got %d calls, want %d", got, tt.wantRuns)
+			}
+		})
+	}
+}
+
+func TestRetryUnaryRespectsDeadline(t *testing.T) {
+	p := fastRetries(5)
+	p.InitialBackoff = time.Second
+	srv, client := newRetryTestClient(t, p)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.UnaryResiliency(ctx, &resl.ResiliencyRequest{StatusCodes: []uint32{unavailable}})
+
+	if got := status.Code(err); got != codes.Unavailable {
+		t.Errorf("code = %v, want the last error %v", got, codes.Unavailable)
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("gave up after %v, want right away", elapsed)
+	}
+
+	if got := len(srv.Resiliency.Calls("UnaryResiliency")); got != 1 {
+		t.Errorf("server got %d calls, want 1", got)
+	}
+}
+
+func recvAll(t *testing.T, stream resl.ResiliencyService_ServerStreamingResiliencyClient) (int, error) {
+	t.Helper()
+
+	n := 0
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			return n, nil
+		}
+
+		if err != nil {
+			return n, err
+		}
+
+		n++
+	}
+}
+
+func TestRetryServerStreamBeforeFirstMessage(t *testing.T) {
+	srv, client := newRetryTestClient(t, fastRetries(3))
+
+	// the first answer fails, the next StreamLength succeed
+	req := &resl.ResiliencyRequest{StatusCodes: []uint32{unavailable, dresl.OK, dresl.OK, dresl.OK}}
+
+	stream, err := client.ServerStreamingResiliency(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ServerStreamingResiliency: %v", err)
+	}
+
+	n, err := recvAll(t, stream)
+	if err != nil {
+		t.Fatalf("stream ended with error: %v", err)
+	}
+
+	if n != fakeserver.StreamLength {
+		t.Errorf("got %d responses, want %d", n, fakeserver.StreamLength)
+	}
+
+	if got := attempts(srv.Resiliency.Calls("ServerStreamingResiliency")); len(got) != 2 || got[1] != "2" {
+		t.Errorf("attempt headers = %v, want [1 2]", got)
+	}
+}
+
+func TestRetryServerStreamNotAfterFirstMessage(t *testing.T) {
+	srv, client := newRetryTestClient(t, fastRetries(3))
+	srv.Resiliency.On("ServerStreamingResiliency", fakeserver.Behavior{
+		Err:       status.Error(codes.Unavailable, "going away"),
+		FailAfter: 1,
+	})
+
+	stream, err := client.ServerStreamingResiliency(context.Background(), &resl.ResiliencyRequest{})
+	if err != nil {
+		t.Fatalf("ServerStreamingResiliency: %v", err)
+	}
+
+	n, err := recvAll(t, stream)
+	if n != 1 || status.Code(err) != codes.Unavailable {
+		t.Errorf("got %d responses and %v, want 1 and %v", n, err, codes.Unavailable)
+	}
+
+	if got := len(srv.Resiliency.Calls("ServerStreamingResiliency")); got != 1 {
+		t.Errorf("server got %d calls, want 1", got)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     3,
+		Jitter:         0.2,
+	}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{2, 100 * time.Millisecond},
+		{3, 300 * time.Millisecond},
+		{4, 900 * time.Millisecond},
+		{5, time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			got := p.backoff(tt.attempt)
+			if got < tt.want*8/10 || got > tt.want*12/10 {
+				t.Fatalf("backoff(%d) = %v, want %v ± 20%%", tt.attempt, got, tt.want)
+			}
+		}
+	}
+}
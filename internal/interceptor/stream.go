@@ -0,0 +1,63 @@
+package interceptor
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// streamEnd tells a stream interceptor once when a stream is over, and with
+// which error, nil when it completed.
+//
+// A stream is over when RecvMsg returns io.EOF or an error, or the single
+// response of a stream the server does not stream. A stream the caller
+// stops reading is over when the context it was opened with is done.
+type streamEnd struct {
+	desc     *grpc.StreamDesc
+	done     func(err error)
+	once     sync.Once
+	finished chan struct{}
+}
+
+// watchStreamEnd returns the streamEnd of a stream opened with ctx and
+// desc, calling done once the stream is over.
+func watchStreamEnd(ctx context.Context, desc *grpc.StreamDesc, done func(err error)) *streamEnd {
+	e := &streamEnd{
+		desc:     desc,
+		done:     done,
+		finished: make(chan struct{}),
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			e.end(status.FromContextError(ctx.Err()).Err())
+		case <-e.finished:
+		}
+	}()
+
+	return e
+}
+
+func (e *streamEnd) end(err error) {
+	e.once.Do(func() {
+		e.done(err)
+		close(e.finished)
+	})
+}
+
+// recv ends the stream if the RecvMsg that returned err was its last.
+func (e *streamEnd) recv(err error) {
+	switch {
+	case err == io.EOF:
+		e.end(nil)
+	case err != nil:
+		e.end(err)
+	case !e.desc.ServerStreams:
+		// the single response of a client stream ends it
+		e.end(nil)
+	}
+}
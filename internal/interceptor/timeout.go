@@ -0,0 +1,174 @@
+package interceptor
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TimeoutFunc returns the timeout of a unary call to a full method name,
+// 0 for none.
+type TimeoutFunc func(method string) time.Duration
+
+// StreamTimeout bounds a stream.
+type StreamTimeout struct {
+	// Lifetime caps the whole stream, 0 for no cap.
+	Lifetime time.Duration
+	// Idle ends the stream once no message was sent or received for this
+	// long, 0 for no limit.
+	Idle time.Duration
+}
+
+// StreamTimeoutFunc returns the stream timeout of a full method name.
+type StreamTimeoutFunc func(method string) StreamTimeout
+
+// withTimeout bounds ctx by timeout, unless it already has an earlier
+// deadline: a timeout only ever shortens the time a call gets.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= timeout {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// TimeoutUnaryClientInterceptor bounds every unary call by the timeout of
+// its method. Chained outside the retry interceptor, the timeout covers
+// every attempt and backoff of the call.
+func TimeoutUnaryClientInterceptor(timeout TimeoutFunc) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, cancel := withTimeout(ctx, timeout(method))
+		defer cancel()
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// TimeoutStreamClientInterceptor bounds every stream by the lifetime and
+// idle timeout of its method. An idle stream fails with
+// codes.DeadlineExceeded. The timers are released once the stream ended,
+// as seen by RecvMsg, or its context is done.
+func TimeoutStreamClientInterceptor(timeout StreamTimeoutFunc) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		t := timeout(method)
+
+		ctx, cancel := withTimeout(ctx, t.Lifetime)
+
+		clientStream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+
+		s := &timeoutClientStream{
+			ClientStream: clientStream,
+			desc:         desc,
+			method:       method,
+			idle:         t.Idle,
+			cancel:       cancel,
+		}
+
+		if t.Idle > 0 {
+			s.mu.Lock()
+			s.timer = time.AfterFunc(t.Idle, s.expire)
+			s.mu.Unlock()
+		}
+
+		// the stream context is done once the stream is over, whichever
+		// way it ended
+		go func() {
+			<-clientStream.Context().Done()
+			s.finish()
+		}()
+
+		return s, nil
+	}
+}
+
+// timeoutClientStream restarts the idle timer on every message and releases
+// the timers when the stream ends.
+type timeoutClientStream struct {
+	grpc.ClientStream
+
+	desc   *grpc.StreamDesc
+	method string
+	idle   time.Duration
+	cancel context.CancelFunc
+	idled  atomic.Bool
+	once   sync.Once
+
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+func (s *timeoutClientStream) expire() {
+	s.idled.Store(true)
+	s.cancel()
+}
+
+func (s *timeoutClientStream) touch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.timer != nil {
+		s.timer.Reset(s.idle)
+	}
+}
+
+func (s *timeoutClientStream) finish() {
+	s.once.Do(func() {
+		s.mu.Lock()
+		if s.timer != nil {
+			s.timer.Stop()
+		}
+		s.mu.Unlock()
+
+		s.cancel()
+	})
+}
+
+// err reports an idle stream as such rather than as cancelled.
+func (s *timeoutClientStream) err(err error) error {
+	if err == nil || err == io.EOF || !s.idled.Load() {
+		return err
+	}
+
+	return status.Errorf(codes.DeadlineExceeded, "stream %v idle for more than %v", s.method, s.idle)
+}
+
+func (s *timeoutClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.touch()
+	}
+
+	return s.err(err)
+}
+
+func (s *timeoutClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+
+	switch {
+	case err != nil:
+		s.finish()
+	case !s.desc.ServerStreams:
+		// the single response of a client stream ends it
+		s.finish()
+	default:
+		s.touch()
+	}
+
+	return s.err(err)
+}
@@ -0,0 +1,110 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fbriansyah/my-grpc-go-client/internal/fakeserver"
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/hello"
+	resl "github.com/fbriansyah/my-grpc-proto/protogen/go/resiliency"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTimeoutTestClient(t *testing.T, unary TimeoutFunc, stream StreamTimeout) (*fakeserver.Server, *grpc.ClientConn) {
+	t.Helper()
+
+	return fakeserver.Start(t,
+		grpc.WithChainUnaryInterceptor(TimeoutUnaryClientInterceptor(unary)),
+		grpc.WithChainStreamInterceptor(TimeoutStreamClientInterceptor(func(string) StreamTimeout { return stream })),
+	)
+}
+
+func TestTimeoutUnaryPerMethod(t *testing.T) {
+	timeout := func(method string) time.Duration {
+		if method == unaryMethod {
+			return 50 * time.Millisecond
+		}
+
+		return 0
+	}
+
+	srv, conn := newTimeoutTestClient(t, timeout, StreamTimeout{})
+	srv.Resiliency.On("UnaryResiliency", fakeserver.Behavior{Delay: 200 * time.Millisecond})
+	srv.Hello.On("SayHello", fakeserver.Behavior{Delay: 100 * time.Millisecond})
+
+	_, err := resl.NewResiliencyServiceClient(conn).UnaryResiliency(context.Background(), &resl.ResiliencyRequest{})
+	if got := status.Code(err); got != codes.DeadlineExceeded {
+		t.Errorf("UnaryResiliency code = %v, want %v", got, codes.DeadlineExceeded)
+	}
+
+	if _, err := hello.NewHelloServiceClient(conn).SayHello(context.Background(), &hello.HelloRequest{}); err != nil {
+		t.Errorf("SayHello without a timeout: %v", err)
+	}
+}
+
+func TestTimeoutUnaryOnlyShortens(t *testing.T) {
+	srv, conn := newTimeoutTestClient(t, func(string) time.Duration { return time.Second }, StreamTimeout{})
+	srv.Resiliency.On("UnaryResiliency", fakeserver.Behavior{Delay: 500 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := resl.NewResiliencyServiceClient(conn).UnaryResiliency(ctx, &resl.ResiliencyRequest{})
+
+	if got := status.Code(err); got != codes.DeadlineExceeded {
+		t.Errorf("code = %v, want %v", got, codes.DeadlineExceeded)
+	}
+
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Errorf("call took %v, want the caller deadline to be kept", elapsed)
+	}
+}
+
+func TestTimeoutStreamLifetime(t *testing.T) {
+	srv, conn := newTimeoutTestClient(t, nil, StreamTimeout{Lifetime: 50 * time.Millisecond})
+	srv.Resiliency.On("ServerStreamingResiliency", fakeserver.Behavior{Delay: 200 * time.Millisecond})
+
+	stream, err := resl.NewResiliencyServiceClient(conn).ServerStreamingResiliency(context.Background(), &resl.ResiliencyRequest{})
+	if err != nil {
+		t.Fatalf("ServerStreamingResiliency: %v", err)
+	}
+
+	if _, err := recvAll(t, stream); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("stream ended with %v, want %v", err, codes.DeadlineExceeded)
+	}
+}
+
+func TestTimeoutStreamIdle(t *testing.T) {
+	_, conn := newTimeoutTestClient(t, nil, StreamTimeout{Idle: 80 * time.Millisecond})
+	client := resl.NewResiliencyServiceClient(conn)
+
+	stream, err := client.BiDirectionalResiliency(context.Background())
+	if err != nil {
+		t.Fatalf("BiDirectionalResiliency: %v", err)
+	}
+
+	// a message every 40ms keeps the stream going well past the idle timeout
+	for i := 0; i < 4; i++ {
+		time.Sleep(40 * time.Millisecond)
+
+		if err := stream.Send(&resl.ResiliencyRequest{}); err != nil {
+			t.Fatalf("Send %d: %v", i, err)
+		}
+
+		if _, err := stream.Recv(); err != nil {
+			t.Fatalf("Recv %d: %v", i, err)
+		}
+	}
+
+	time.Sleep(150 * time.Millisecond)
+
+	_, err = stream.Recv()
+	if got := status.Code(err); got != codes.DeadlineExceeded || !strings.Contains(err.Error(), "idle") {
+		t.Errorf("idle stream ended with %v, want %v for being idle", err, codes.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,172 @@
+package interceptor
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tracerName is the name of the tracer creating the client spans.
+const tracerName = "github.com/fbriansyah/my-grpc-go-client/internal/interceptor"
+
+// TraceOptions configure the tracing interceptors.
+type TraceOptions struct {
+	// Provider creates the spans, the global provider when nil.
+	Provider trace.TracerProvider
+	// Propagator writes the trace context into the outgoing metadata, W3C
+	// traceparent and tracestate when nil.
+	Propagator propagation.TextMapPropagator
+}
+
+func (o TraceOptions) tracer() trace.Tracer {
+	provider := o.Provider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	return provider.Tracer(tracerName)
+}
+
+func (o TraceOptions) propagator() propagation.TextMapPropagator {
+	if o.Propagator == nil {
+		return propagation.TraceContext{}
+	}
+
+	return o.Propagator
+}
+
+// metadataCarrier lets a propagator write into gRPC metadata.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// startSpan starts the client span of a call and returns ctx carrying it,
+// with the trace context added to the outgoing metadata.
+func (o TraceOptions) startSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	service, name := splitMethod(method)
+
+	ctx, span := o.tracer().Start(ctx, service+"/"+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.RPCSystemGRPC,
+			semconv.RPCService(service),
+			semconv.RPCMethod(name),
+		),
+	)
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	o.propagator().Inject(ctx, metadataCarrier(md))
+
+	return metadata.NewOutgoingContext(ctx, md), span
+}
+
+// endSpan records the status of a call on its span and ends it.
+func endSpan(span trace.Span, err error) {
+	s := status.Convert(err)
+
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(s.Code())))
+	if err != nil {
+		span.SetStatus(otelcodes.Error, s.Message())
+	}
+
+	span.End()
+}
+
+// TraceUnaryClientInterceptor starts a client span for every unary call and
+// sends its trace context with the call.
+func TraceUnaryClientInterceptor(o TraceOptions) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := o.startSpan(ctx, method)
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		endSpan(span, err)
+
+		return err
+	}
+}
+
+// TraceStreamClientInterceptor starts a client span for every stream and
+// sends its trace context with the stream. Every message sent and received
+// is an event of the span, which ends with the stream, see streamEnd.
+func TraceStreamClientInterceptor(o TraceOptions) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, span := o.startSpan(ctx, method)
+
+		clientStream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			endSpan(span, err)
+			return nil, err
+		}
+
+		return &tracedClientStream{
+			ClientStream: clientStream,
+			span:         span,
+			end:          watchStreamEnd(ctx, desc, func(err error) { endSpan(span, err) }),
+		}, nil
+	}
+}
+
+// tracedClientStream adds the messages of a stream to its span.
+type tracedClientStream struct {
+	grpc.ClientStream
+
+	span           trace.Span
+	end            *streamEnd
+	sent, received atomic.Int64
+}
+
+func (s *tracedClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.span.AddEvent("message", trace.WithAttributes(
+			semconv.MessageTypeSent,
+			semconv.MessageID(int(s.sent.Add(1))),
+		))
+	}
+
+	return err
+}
+
+func (s *tracedClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil {
+		s.span.AddEvent("message", trace.WithAttributes(
+			semconv.MessageTypeReceived,
+			semconv.MessageID(int(s.received.Add(1))),
+		))
+	}
+
+	s.end.recv(err)
+
+	return err
+}
@@ -0,0 +1,107 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	dresl "github.com/fbriansyah/my-grpc-go-client/internal/application/domain/resiliency"
+	"github.com/fbriansyah/my-grpc-go-client/internal/fakeserver"
+	resl "github.com/fbriansyah/my-grpc-proto/protogen/go/resiliency"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTraceTestClient(t *testing.T) (*fakeserver.Server, resl.ResiliencyServiceClient, *tracetest.SpanRecorder) {
+	t.Helper()
+
+	spans := tracetest.NewSpanRecorder()
+	o := TraceOptions{Provider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))}
+
+	srv, conn := fakeserver.Start(t,
+		grpc.WithChainUnaryInterceptor(TraceUnaryClientInterceptor(o)),
+		grpc.WithChainStreamInterceptor(TraceStreamClientInterceptor(o)),
+	)
+
+	return srv, resl.NewResiliencyServiceClient(conn), spans
+}
+
+func statusCodeAttr(span sdktrace.ReadOnlySpan) int64 {
+	for _, kv := range span.Attributes() {
+		if kv.Key == semconv.RPCGRPCStatusCodeKey {
+			return kv.Value.AsInt64()
+		}
+	}
+
+	return -1
+}
+
+func TestTraceUnaryPropagates(t *testing.T) {
+	srv, client, spans := newTraceTestClient(t)
+
+	_, err := client.UnaryResiliency(context.Background(),
+		&resl.ResiliencyRequest{StatusCodes: []uint32{dresl.NOT_FOUND}})
+	if got := status.Code(err); got != codes.NotFound {
+		t.Fatalf("code = %v, want %v", got, codes.NotFound)
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("got %d spans, want 1", len(ended))
+	}
+
+	span := ended[0]
+	if span.Name() != "resiliency.ResiliencyService/UnaryResiliency" || span.SpanKind() != trace.SpanKindClient {
+		t.Errorf("span %v of kind %v", span.Name(), span.SpanKind())
+	}
+
+	if got := statusCodeAttr(span); got != int64(codes.NotFound) {
+		t.Errorf("status code attribute = %v, want %v", got, int64(codes.NotFound))
+	}
+
+	if span.Status().Code != otelcodes.Error {
+		t.Errorf("span status = %v, want %v", span.Status().Code, otelcodes.Error)
+	}
+
+	traceparent := srv.Resiliency.Calls("UnaryResiliency")[0].Metadata.Get("traceparent")
+	if len(traceparent) != 1 || !strings.Contains(traceparent[0], span.SpanContext().TraceID().String()) {
+		t.Errorf("traceparent = %v, want the trace %v", traceparent, span.SpanContext().TraceID())
+	}
+}
+
+func TestTraceStreamEvents(t *testing.T) {
+	_, client, spans := newTraceTestClient(t)
+
+	stream, err := client.ServerStreamingResiliency(context.Background(), &resl.ResiliencyRequest{})
+	if err != nil {
+		t.Fatalf("ServerStreamingResiliency: %v", err)
+	}
+
+	if _, err := recvAll(t, stream); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("got %d spans, want 1", len(ended))
+	}
+
+	span := ended[0]
+	if got, want := len(span.Events()), 1+fakeserver.StreamLength; got != want {
+		t.Errorf("got %d message events, want %d", got, want)
+	}
+
+	if got := statusCodeAttr(span); got != int64(codes.OK) {
+		t.Errorf("status code attribute = %v, want %v", got, int64(codes.OK))
+	}
+
+	if span.Status().Code == otelcodes.Error {
+		t.Errorf("span status = %v", span.Status())
+	}
+}
@@ -0,0 +1,120 @@
+package interceptor
+
+import (
+	"context"
+	"reflect"
+
+	hello_proto "github.com/fbriansyah/my-grpc-proto/protogen/go/hello"
+	resl_proto "github.com/fbriansyah/my-grpc-proto/protogen/go/resiliency"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// Transformers holds the functions changing outgoing requests and incoming
+// responses, per message type. Register them before the first call, they
+// run in registration order.
+type Transformers struct {
+	requests  map[reflect.Type][]func(interface{})
+	responses map[reflect.Type][]func(interface{})
+}
+
+// NewTransformers returns an empty pipeline, leaving every message as is.
+func NewTransformers() *Transformers {
+	return &Transformers{
+		requests:  map[reflect.Type][]func(interface{}){},
+		responses: map[reflect.Type][]func(interface{}){},
+	}
+}
+
+func register[T proto.Message](m map[reflect.Type][]func(interface{}), f func(T)) {
+	var zero T
+	key := reflect.TypeOf(zero)
+
+	m[key] = append(m[key], func(msg interface{}) { f(msg.(T)) })
+}
+
+// OnRequest has f change every request of type T before it is sent.
+func OnRequest[T proto.Message](t *Transformers, f func(T)) {
+	register(t.requests, f)
+}
+
+// OnResponse has f change every response of type T once it is received.
+func OnResponse[T proto.Message](t *Transformers, f func(T)) {
+	register(t.responses, f)
+}
+
+func apply(m map[reflect.Type][]func(interface{}), msg interface{}) {
+	for _, f := range m[reflect.TypeOf(msg)] {
+		f(msg)
+	}
+}
+
+// TransformUnaryClientInterceptor runs the transformers on the request and
+// response of every unary call. It must sit outside the retry interceptor,
+// so a retried request is not changed again.
+func TransformUnaryClientInterceptor(t *Transformers) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		apply(t.requests, req)
+
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return err
+		}
+
+		apply(t.responses, reply)
+
+		return nil
+	}
+}
+
+// TransformStreamClientInterceptor runs the transformers on every message
+// sent and received by a stream, like TransformUnaryClientInterceptor.
+func TransformStreamClientInterceptor(t *Transformers) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		clientStream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return &transformClientStream{ClientStream: clientStream, transformers: t}, nil
+	}
+}
+
+type transformClientStream struct {
+	grpc.ClientStream
+	transformers *Transformers
+}
+
+func (s *transformClientStream) SendMsg(m interface{}) error {
+	apply(s.transformers.requests, m)
+
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *transformClientStream) RecvMsg(m interface{}) error {
+	if err := s.ClientStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	apply(s.transformers.responses, m)
+
+	return nil
+}
+
+// RegisterDemoTransformers registers the changes the client used to make
+// to every hello and resiliency call, to show what a transformer can do.
+// They corrupt real data, so they are only registered on demand.
+func RegisterDemoTransformers(t *Transformers) {
+	OnRequest(t, func(request *hello_proto.HelloRequest) {
+		request.Name = "[MODIFIED BY CLIENT INTERCEPTOR]" + request.Name
+	})
+
+	OnResponse(t, func(response *hello_proto.HelloResponse) {
+		response.Greet = "[MODIFIED BY CLIENT INTERCEPTOR]" + response.Greet
+	})
+
+	OnResponse(t, func(response *resl_proto.ResiliencyResponse) {
+		response.DummyString = "[MODIFIED BY CLIENT INTERCEPTOR]" + response.DummyString
+	})
+}
@@ -0,0 +1,83 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fbriansyah/my-grpc-go-client/internal/fakeserver"
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/hello"
+	resl "github.com/fbriansyah/my-grpc-proto/protogen/go/resiliency"
+	"google.golang.org/grpc"
+)
+
+func newTransformTestClient(t *testing.T, transformers *Transformers) *grpc.ClientConn {
+	t.Helper()
+
+	_, conn := fakeserver.Start(t,
+		grpc.WithChainUnaryInterceptor(TransformUnaryClientInterceptor(transformers)),
+		grpc.WithChainStreamInterceptor(TransformStreamClientInterceptor(transformers)),
+	)
+
+	return conn
+}
+
+func upperNames() *Transformers {
+	transformers := NewTransformers()
+
+	OnRequest(transformers, func(req *hello.HelloRequest) { req.Name = strings.ToUpper(req.Name) })
+	OnResponse(transformers, func(res *hello.HelloResponse) { res.Greet += "!" })
+	OnResponse(transformers, func(res *hello.HelloResponse) { res.Greet += "?" })
+
+	return transformers
+}
+
+func TestTransformUnary(t *testing.T) {
+	client := hello.NewHelloServiceClient(newTransformTestClient(t, upperNames()))
+
+	res, err := client.SayHello(context.Background(), &hello.HelloRequest{Name: "budi"})
+	if err != nil {
+		t.Fatalf("SayHello: %v", err)
+	}
+
+	if want := "Hello BUDI!?"; res.Greet != want {
+		t.Errorf("greet = %q, want %q", res.Greet, want)
+	}
+}
+
+func TestTransformStream(t *testing.T) {
+	client := hello.NewHelloServiceClient(newTransformTestClient(t, upperNames()))
+
+	stream, err := client.SayHelloToEveryone(context.Background())
+	if err != nil {
+		t.Fatalf("SayHelloToEveryone: %v", err)
+	}
+
+	for _, name := range []string{"a", "b"} {
+		if err := stream.Send(&hello.HelloRequest{Name: name}); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("CloseAndRecv: %v", err)
+	}
+
+	if want := "Hello A, B!?"; res.Greet != want {
+		t.Errorf("greet = %q, want %q", res.Greet, want)
+	}
+}
+
+func TestTransformOtherTypesUntouched(t *testing.T) {
+	client := resl.NewResiliencyServiceClient(newTransformTestClient(t, upperNames()))
+
+	res, err := client.UnaryResiliency(context.Background(), &resl.ResiliencyRequest{})
+	if err != nil {
+		t.Fatalf("UnaryResiliency: %v", err)
+	}
+
+	if strings.HasSuffix(res.DummyString, "?") {
+		t.Errorf("dummy string = %q, changed by a hello transformer", res.DummyString)
+	}
+}
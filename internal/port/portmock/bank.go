@@ -0,0 +1,71 @@
+package portmock
+
+import (
+	"context"
+
+	"github.com/fbriansyah/my-grpc-go-client/internal/port"
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/bank"
+	"google.golang.org/grpc"
+)
+
+var _ port.BankClientPort = (*BankClient)(nil)
+
+// BankClient is a port.BankClientPort calling the func of each method,
+// methods without one fail with codes.Unimplemented.
+type BankClient struct {
+	GetCurrentBalanceFunc func(ctx context.Context, in *bank.CurrentBalanceRequest,
+		opts ...grpc.CallOption) (*bank.CurrentBalanceResponse, error)
+	FetchExchangeRatesFunc func(ctx context.Context, in *bank.ExchangeRateRequest,
+		opts ...grpc.CallOption) (bank.BankService_FetchExchangeRatesClient, error)
+	SummarizeTransactionsFunc func(ctx context.Context,
+		opts ...grpc.CallOption) (bank.BankService_SummarizeTransactionsClient, error)
+	TransferMultipleFunc func(ctx context.Context,
+		opts ...grpc.CallOption) (bank.BankService_TransferMultipleClient, error)
+	CreateAccountFunc func(ctx context.Context, in *bank.CreateAccountRequest,
+		opts ...grpc.CallOption) (*bank.CreateAccountResponse, error)
+}
+
+func (c *BankClient) GetCurrentBalance(ctx context.Context, in *bank.CurrentBalanceRequest,
+	opts ...grpc.CallOption) (*bank.CurrentBalanceResponse, error) {
+	if c.GetCurrentBalanceFunc == nil {
+		return nil, unimplemented("GetCurrentBalance")
+	}
+
+	return c.GetCurrentBalanceFunc(ctx, in, opts...)
+}
+
+func (c *BankClient) FetchExchangeRates(ctx context.Context, in *bank.ExchangeRateRequest,
+	opts ...grpc.CallOption) (bank.BankService_FetchExchangeRatesClient, error) {
+	if c.FetchExchangeRatesFunc == nil {
+		return nil, unimplemented("FetchExchangeRates")
+	}
+
+	return c.FetchExchangeRatesFunc(ctx, in, opts...)
+}
+
+func (c *BankClient) SummarizeTransactions(ctx context.Context,
+	opts ...grpc.CallOption) (bank.BankService_SummarizeTransactionsClient, error) {
+	if c.SummarizeTransactionsFunc == nil {
+		return nil, unimplemented("SummarizeTransactions")
+	}
+
+	return c.SummarizeTransactionsFunc(ctx, opts...)
+}
+
+func (c *BankClient) TransferMultiple(ctx context.Context,
+	opts ...grpc.CallOption) (bank.BankService_TransferMultipleClient, error) {
+	if c.TransferMultipleFunc == nil {
+		return nil, unimplemented("TransferMultiple")
+	}
+
+	return c.TransferMultipleFunc(ctx, opts...)
+}
+
+func (c *BankClient) CreateAccount(ctx context.Context, in *bank.CreateAccountRequest,
+	opts ...grpc.CallOption) (*bank.CreateAccountResponse, error) {
+	if c.CreateAccountFunc == nil {
+		return nil, unimplemented("CreateAccount")
+	}
+
+	return c.CreateAccountFunc(ctx, in, opts...)
+}
@@ -0,0 +1,60 @@
+package portmock
+
+import (
+	"context"
+
+	"github.com/fbriansyah/my-grpc-go-client/internal/port"
+	"github.com/fbriansyah/my-grpc-proto/protogen/go/hello"
+	"google.golang.org/grpc"
+)
+
+var _ port.HelloClientPort = (*HelloClient)(nil)
+
+// HelloClient is a port.HelloClientPort calling the func of each method,
+// methods without one fail with codes.Unimplemented.
+type HelloClient struct {
+	SayHelloFunc func(ctx context.Context, in *hello.HelloRequest,
+		opts ...grpc.CallOption) (*hello.HelloResponse, error)
+	SayManyHelloFunc func(ctx context.Context, in *hello.HelloRequest,
+		opts ...grpc.CallOption) (hello.HelloService_SayManyHelloClient, error)
+	SayHelloToEveryoneFunc func(ctx context.Context,
+		opts ...grpc.CallOption) (hello.HelloService_SayHelloToEveryoneClient, error)
+	SayHelloContinuousFunc func(ctx context.Context,
+		opts ...grpc.CallOption) (hello.HelloService_SayHelloContinuousClient, error)
+}
+
+func (c *HelloClient) SayHello(ctx context.Context, in *hello.HelloRequest,
+	opts ...grpc.CallOption) (*hello.HelloResponse, error) {
+	if c.SayHelloFunc == nil {
+		return nil, unimplemented("SayHello")
+	}
+
+	return c.SayHelloFunc(ctx, in, opts...)
+}
+
+func (c *HelloClient) SayManyHello(ctx context.Context, in *hello.HelloRequest,
+	opts ...grpc.CallOption) (hello.HelloService_SayManyHelloClient, error) {
+	if c.SayManyHelloFunc == nil {
+		return nil, unimplemented("SayManyHello")
+	}
+
+	return c.SayManyHelloFunc(ctx, in, opts...)
+}
+
+func (c *HelloClient) SayHelloToEveryone(ctx context.Context,
+	opts ...grpc.CallOption) (hello.HelloService_SayHelloToEveryoneClient, error) {
+	if c.SayHelloToEveryoneFunc == nil {
+		return nil, unimplemented("SayHelloToEveryone")
+	}
+
+	return c.SayHelloToEveryoneFunc(ctx, opts...)
+}
+
+func (c *HelloClient) SayHelloContinuous(ctx context.Context,
+	opts ...grpc.CallOption) (hello.HelloService_SayHelloContinuousClient, error) {
+	if c.SayHelloContinuousFunc == nil {
+		return nil, unimplemented("SayHelloContinuous")
+	}
+
+	return c.SayHelloContinuousFunc(ctx, opts...)
+}
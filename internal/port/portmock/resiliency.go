@@ -0,0 +1,116 @@
+package portmock
+
+import (
+	"context"
+
+	"github.com/fbriansyah/my-grpc-go-client/internal/port"
+	resl "github.com/fbriansyah/my-grpc-proto/protogen/go/resiliency"
+	"google.golang.org/grpc"
+)
+
+var (
+	_ port.ResiliencyClietnPort             = (*ResiliencyClient)(nil)
+	_ port.ResiliencyWithMetadataClientPort = (*ResiliencyWithMetadataClient)(nil)
+)
+
+// ResiliencyClient is a port.ResiliencyClietnPort calling the func of each
+// method, methods without one fail with codes.Unimplemented.
+type ResiliencyClient struct {
+	UnaryResiliencyFunc func(ctx context.Context, in *resl.ResiliencyRequest,
+		opts ...grpc.CallOption) (*resl.ResiliencyResponse, error)
+	ServerStreamingResiliencyFunc func(ctx context.Context, in *resl.ResiliencyRequest,
+		opts ...grpc.CallOption) (resl.ResiliencyService_ServerStreamingResiliencyClient, error)
+	ClientStreamingResiliencyFunc func(ctx context.Context,
+		opts ...grpc.CallOption) (resl.ResiliencyService_ClientStreamingResiliencyClient, error)
+	BiDirectionalResiliencyFunc func(ctx context.Context,
+		opts ...grpc.CallOption) (resl.ResiliencyService_BiDirectionalResiliencyClient, error)
+}
+
+func (c *ResiliencyClient) UnaryResiliency(ctx context.Context, in *resl.ResiliencyRequest,
+	opts ...grpc.CallOption) (*resl.ResiliencyResponse, error) {
+	if c.UnaryResiliencyFunc == nil {
+		return nil, unimplemented("UnaryResiliency")
+	}
+
+	return c.UnaryResiliencyFunc(ctx, in, opts...)
+}
+
+func (c *ResiliencyClient) ServerStreamingResiliency(ctx context.Context, in *resl.ResiliencyRequest,
+	opts ...grpc.CallOption) (resl.ResiliencyService_ServerStreamingResiliencyClient, error) {
+	if c.ServerStreamingResiliencyFunc == nil {
+		return nil, unimplemented("ServerStreamingResiliency")
+	}
+
+	return c.ServerStreamingResiliencyFunc(ctx, in, opts...)
+}
+
+func (c *ResiliencyClient) ClientStreamingResiliency(ctx context.Context,
+	opts ...grpc.CallOption) (resl.ResiliencyService_ClientStreamingResiliencyClient, error) {
+	if c.ClientStreamingResiliencyFunc == nil {
+		return nil, unimplemented("ClientStreamingResiliency")
+	}
+
+	return c.ClientStreamingResiliencyFunc(ctx, opts...)
+}
+
+func (c *ResiliencyClient) BiDirectionalResiliency(ctx context.Context,
+	opts ...grpc.CallOption) (resl.ResiliencyService_BiDirectionalResiliencyClient, error) {
+	if c.BiDirectionalResiliencyFunc == nil {
+		return nil, unimplemented("BiDirectionalResiliency")
+	}
+
+	return c.BiDirectionalResiliencyFunc(ctx, opts...)
+}
+
+// ResiliencyWithMetadataClient is a port.ResiliencyWithMetadataClientPort
+// calling the func of each method, methods without one fail with
+// codes.Unimplemented.
+type ResiliencyWithMetadataClient struct {
+	UnaryResiliencyWithMetadataFunc func(ctx context.Context, in *resl.ResiliencyRequest,
+		opts ...grpc.CallOption) (*resl.ResiliencyResponse, error)
+	ServerStreamingResiliencyWithMetadataFunc func(ctx context.Context, in *resl.ResiliencyRequest,
+		opts ...grpc.CallOption) (resl.ResiliencyWithMetadataService_ServerStreamingResiliencyWithMetadataClient, error)
+	ClientStreamingResiliencyWithMetadataFunc func(ctx context.Context,
+		opts ...grpc.CallOption) (resl.ResiliencyWithMetadataService_ClientStreamingResiliencyWithMetadataClient, error)
+	BiDirectionalResiliencyWithMetadataFunc func(ctx context.Context,
+		opts ...grpc.CallOption) (resl.ResiliencyWithMetadataService_BiDirectionalResiliencyWithMetadataClient, error)
+}
+
+func (c *ResiliencyWithMetadataClient) UnaryResiliencyWithMetadata(ctx context.Context,
+	in *resl.ResiliencyRequest, opts ...grpc.CallOption) (*resl.ResiliencyResponse, error) {
+	if c.UnaryResiliencyWithMetadataFunc == nil {
+		return nil, unimplemented("UnaryResiliencyWithMetadata")
+	}
+
+	return c.UnaryResiliencyWithMetadataFunc(ctx, in, opts...)
+}
+
+func (c *ResiliencyWithMetadataClient) ServerStreamingResiliencyWithMetadata(ctx context.Context,
+	in *resl.ResiliencyRequest, opts ...grpc.CallOption) (
+	resl.ResiliencyWithMetadataService_ServerStreamingResiliencyWithMetadataClient, error) {
+	if c.ServerStreamingResiliencyWithMetadataFunc == nil {
+		return nil, unimplemented("ServerStreamingResiliencyWithMetadata")
+	}
+
+	return c.ServerStreamingResiliencyWithMetadataFunc(ctx, in, opts...)
+}
+
+func (c *ResiliencyWithMetadataClient) ClientStreamingResiliencyWithMetadata(ctx context.Context,
+	opts ...grpc.CallOption) (
+	resl.ResiliencyWithMetadataService_ClientStreamingResiliencyWithMetadataClient, error) {
+	if c.ClientStreamingResiliencyWithMetadataFunc == nil {
+		return nil, unimplemented("ClientStreamingResiliencyWithMetadata")
+	}
+
+	return c.ClientStreamingResiliencyWithMetadataFunc(ctx, opts...)
+}
+
+func (c *ResiliencyWithMetadataClient) BiDirectionalResiliencyWithMetadata(ctx context.Context,
+	opts ...grpc.CallOption) (
+	resl.ResiliencyWithMetadataService_BiDirectionalResiliencyWithMetadataClient, error) {
+	if c.BiDirectionalResiliencyWithMetadataFunc == nil {
+		return nil, unimplemented("BiDirectionalResiliencyWithMetadata")
+	}
+
+	return c.BiDirectionalResiliencyWithMetadataFunc(ctx, opts...)
+}
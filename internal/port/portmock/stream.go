@@ -0,0 +1,147 @@
+// Package portmock provides scripted implementations of the port interfaces
+// and of the stream clients they return, to unit test the adapters without a
+// server.
+package portmock
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ClientStream is a scripted stream client, it implements every generated
+// stream client interface of Req and Res, e.g. a
+// *ClientStream[*hello.HelloRequest, *hello.HelloResponse] is a
+// hello.HelloService_SayHelloContinuousClient.
+//
+// Recv returns Responses in order, then Err, or io.EOF when Err is nil.
+// CloseAndRecv returns Err, or the first of Responses. Send records the
+// requests, or fails with SendErr once it is set.
+type ClientStream[Req, Res any] struct {
+	Responses []Res
+	Err       error
+	SendErr   error
+	HeaderMD  metadata.MD
+	TrailerMD metadata.MD
+	// Ctx is returned by Context, context.Background when nil.
+	Ctx context.Context
+
+	mu         sync.Mutex
+	sent       []Req
+	next       int
+	sendClosed bool
+}
+
+func (s *ClientStream[Req, Res]) Send(req Req) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.SendErr != nil {
+		return s.SendErr
+	}
+
+	if s.sendClosed {
+		return errors.New("portmock: send on closed stream")
+	}
+
+	s.sent = append(s.sent, req)
+
+	return nil
+}
+
+func (s *ClientStream[Req, Res]) Recv() (Res, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var zero Res
+
+	if s.next < len(s.Responses) {
+		s.next++
+		return s.Responses[s.next-1], nil
+	}
+
+	if s.Err != nil {
+		return zero, s.Err
+	}
+
+	return zero, io.EOF
+}
+
+func (s *ClientStream[Req, Res]) CloseAndRecv() (Res, error) {
+	s.CloseSend()
+
+	var zero Res
+
+	if s.Err != nil {
+		return zero, s.Err
+	}
+
+	if len(s.Responses) == 0 {
+		return zero, status.Error(codes.Internal, "portmock: no response scripted")
+	}
+
+	return s.Responses[0], nil
+}
+
+// Sent returns the requests sent so far.
+func (s *ClientStream[Req, Res]) Sent() []Req {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Req(nil), s.sent...)
+}
+
+// SendClosed reports whether CloseSend or CloseAndRecv was called.
+func (s *ClientStream[Req, Res]) SendClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sendClosed
+}
+
+func (s *ClientStream[Req, Res]) Header() (metadata.MD, error) {
+	return s.HeaderMD, nil
+}
+
+func (s *ClientStream[Req, Res]) Trailer() metadata.MD {
+	return s.TrailerMD
+}
+
+func (s *ClientStream[Req, Res]) CloseSend() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sendClosed = true
+
+	return nil
+}
+
+func (s *ClientStream[Req, Res]) Context() context.Context {
+	if s.Ctx == nil {
+		return context.Background()
+	}
+
+	return s.Ctx
+}
+
+func (s *ClientStream[Req, Res]) SendMsg(m any) error {
+	req, ok := m.(Req)
+	if !ok {
+		return status.Errorf(codes.Internal, "portmock: cannot send %T", m)
+	}
+
+	return s.Send(req)
+}
+
+func (s *ClientStream[Req, Res]) RecvMsg(m any) error {
+	return status.Error(codes.Unimplemented, "portmock: RecvMsg is not supported, use Recv")
+}
+
+func unimplemented(method string) error {
+	return status.Errorf(codes.Unimplemented, "portmock: %v is not scripted", method)
+}
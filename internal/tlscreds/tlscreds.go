@@ -0,0 +1,215 @@
+// Package tlscreds builds the TLS transport credentials of the client. The
+// CA bundle and the client certificate are read from disk and reloaded when
+// the files change, so certificates can be rotated without a restart.
+package tlscreds
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// Options locate the certificates of a TLS connection.
+type Options struct {
+	// CAFile is a PEM bundle of the CAs trusted to sign the server
+	// certificate, the system pool is used when it is empty.
+	CAFile string
+	// CertFile and KeyFile are the PEM client certificate and key sent for
+	// mutual TLS, no certificate is sent when they are empty.
+	CertFile string
+	KeyFile  string
+	// ServerName is checked against the server certificate instead of the
+	// host of the target.
+	ServerName string
+	// ReloadInterval is how often the files are checked for changes, 0
+	// never reloads them. The check runs on the next handshake, so
+	// established connections keep the certificates they were opened with.
+	ReloadInterval time.Duration
+}
+
+// tlsConfig reads the files of o.
+func (o Options) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName: o.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tlscreds: %w", err)
+		}
+
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tlscreds: no certificate found in %v", o.CAFile)
+		}
+	}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("tlscreds: %w", err)
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+func (o Options) files() []string {
+	var files []string
+	for _, f := range []string{o.CAFile, o.CertFile, o.KeyFile} {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+
+	return files
+}
+
+// modTimes returns the modification times of the files of o.
+func (o Options) modTimes() ([]time.Time, error) {
+	var times []time.Time
+	for _, f := range o.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			return nil, fmt.Errorf("tlscreds: %w", err)
+		}
+
+		times = append(times, info.ModTime())
+	}
+
+	return times, nil
+}
+
+// New returns TLS credentials built from the files of o, failing when they
+// cannot be read.
+func New(o Options) (credentials.TransportCredentials, error) {
+	c := &reloadingCredentials{opts: o}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// reloadingCredentials hands every handshake to TLS credentials built from
+// the current content of the files.
+type reloadingCredentials struct {
+	mu       sync.Mutex
+	opts     Options
+	creds    credentials.TransportCredentials
+	modTimes []time.Time
+	checked  time.Time
+}
+
+// load reads the files. The caller holds mu, or owns c.
+func (c *reloadingCredentials) load() error {
+	modTimes, err := c.opts.modTimes()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := c.opts.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	c.creds = credentials.NewTLS(cfg)
+	c.modTimes = modTimes
+	c.checked = time.Now()
+
+	return nil
+}
+
+// changed reports whether a file was modified since it was loaded.
+func (c *reloadingCredentials) changed() (bool, error) {
+	modTimes, err := c.opts.modTimes()
+	if err != nil {
+		return false, err
+	}
+
+	for i := range modTimes {
+		if !modTimes[i].Equal(c.modTimes[i]) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// current returns the credentials of a new handshake, reloading the files
+// first when the reload interval elapsed and they changed. A file that
+// cannot be read keeps the previous credentials, and is read again on the
+// next check.
+func (c *reloadingCredentials) current() credentials.TransportCredentials {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.opts.ReloadInterval <= 0 || time.Since(c.checked) < c.opts.ReloadInterval {
+		return c.creds
+	}
+	c.checked = time.Now()
+
+	changed, err := c.changed()
+	if err == nil && changed {
+		err = c.load()
+		if err == nil {
+			log.Println("TLS certificates reloaded from", c.opts.files())
+		}
+	}
+
+	if err != nil {
+		log.Println("Cannot reload TLS certificates, keeping the previous ones :", err)
+	}
+
+	return c.creds
+}
+
+func (c *reloadingCredentials) ClientHandshake(ctx context.Context, authority string,
+	rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return c.current().ClientHandshake(ctx, authority, rawConn)
+}
+
+func (c *reloadingCredentials) ServerHandshake(rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, fmt.Errorf("tlscreds: client credentials cannot serve")
+}
+
+func (c *reloadingCredentials) Info() credentials.ProtocolInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.creds.Info()
+}
+
+func (c *reloadingCredentials) Clone() credentials.TransportCredentials {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return &reloadingCredentials{
+		opts:     c.opts,
+		creds:    c.creds.Clone(),
+		modTimes: c.modTimes,
+		checked:  c.checked,
+	}
+}
+
+// OverrideServerName is deprecated in grpc, set Options.ServerName instead.
+func (c *reloadingCredentials) OverrideServerName(serverName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.opts.ServerName = serverName
+
+	return c.creds.OverrideServerName(serverName)
+}
@@ -0,0 +1,254 @@
+package tlscreds
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// testCA signs the certificates of a test.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	ca := &testCA{}
+	ca.cert, ca.key = newCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test CA"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil)
+
+	ca.pool = x509.NewCertPool()
+	ca.pool.AddCert(ca.cert)
+
+	return ca
+}
+
+// newCert creates a certificate from template, self-signed when ca is nil.
+func newCert(t *testing.T, template *x509.Certificate, ca *testCA) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	parent, signer := template, key
+	if ca != nil {
+		parent, signer = ca.cert, ca.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, key
+}
+
+// issue returns a certificate of ca for name, usable by a server and a
+// client.
+func (ca *testCA) issue(t *testing.T, name string) tls.Certificate {
+	t.Helper()
+
+	cert, key := newCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: name},
+		DNSNames:    []string{name},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}, ca)
+
+	return tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key, Leaf: cert}
+}
+
+// write saves cert and its key as PEM files in dir, returning their paths.
+func write(t *testing.T, dir string, cert tls.Certificate) (certFile, keyFile string) {
+	t.Helper()
+
+	keyDER, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", cert.Certificate[0])
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+
+	return certFile, keyFile
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// handshake runs a TLS handshake of creds against a server with cert,
+// requiring a client certificate of clientCAs when it is not nil. It
+// returns the client certificate seen by the server.
+func handshake(t *testing.T, creds credentials.TransportCredentials, authority string,
+	cert tls.Certificate, clientCAs *x509.CertPool) (*x509.Certificate, error) {
+	t.Helper()
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}, NextProtos: []string{"h2"}}
+	if clientCAs != nil {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		cfg.ClientCAs = clientCAs
+	}
+
+	server := tls.Server(serverConn, cfg)
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- server.Handshake() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, _, err := creds.ClientHandshake(ctx, authority, clientConn)
+	if err != nil {
+		serverConn.Close()
+		<-serverErr
+		return nil, err
+	}
+
+	if err := <-serverErr; err != nil {
+		return nil, err
+	}
+
+	if peers := server.ConnectionState().PeerCertificates; len(peers) > 0 {
+		return peers[0], nil
+	}
+
+	return nil, nil
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	writePEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", ca.cert.Raw)
+	certFile, keyFile := write(t, dir, ca.issue(t, "client-a"))
+
+	creds, err := New(Options{CAFile: filepath.Join(dir, "ca.pem"), CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	got, err := handshake(t, creds, "localhost:9090", ca.issue(t, "localhost"), ca.pool)
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+
+	if got == nil || got.Subject.CommonName != "client-a" {
+		t.Errorf("server saw client certificate %v, want client-a", got)
+	}
+
+	// a server signed by another CA is not trusted
+	if _, err := handshake(t, creds, "localhost:9090", newTestCA(t).issue(t, "localhost"), nil); err == nil {
+		t.Error("handshake with an unknown CA succeeded")
+	}
+}
+
+func TestServerNameOverride(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	writePEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", ca.cert.Raw)
+	serverCert := ca.issue(t, "bank.internal")
+
+	creds, err := New(Options{CAFile: filepath.Join(dir, "ca.pem")})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if _, err := handshake(t, creds, "10.0.0.1:9090", serverCert, nil); err == nil {
+		t.Error("handshake without the server name override succeeded")
+	}
+
+	creds, err = New(Options{CAFile: filepath.Join(dir, "ca.pem"), ServerName: "bank.internal"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if _, err := handshake(t, creds, "10.0.0.1:9090", serverCert, nil); err != nil {
+		t.Errorf("handshake with the server name override: %v", err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	writePEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", ca.cert.Raw)
+	certFile, keyFile := write(t, dir, ca.issue(t, "client-a"))
+	serverCert := ca.issue(t, "localhost")
+
+	creds, err := New(Options{
+		CAFile:         filepath.Join(dir, "ca.pem"),
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ReloadInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// a half written rotation keeps the previous certificate
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(keyFile, later, later)
+	time.Sleep(2 * time.Millisecond)
+
+	got, err := handshake(t, creds, "localhost:9090", serverCert, ca.pool)
+	if err != nil || got.Subject.CommonName != "client-a" {
+		t.Fatalf("handshake during rotation got %v, %v, want client-a", got, err)
+	}
+
+	write(t, dir, ca.issue(t, "client-b"))
+	later = later.Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	os.Chtimes(keyFile, later, later)
+	time.Sleep(2 * time.Millisecond)
+
+	got, err = handshake(t, creds, "localhost:9090", serverCert, ca.pool)
+	if err != nil || got.Subject.CommonName != "client-b" {
+		t.Fatalf("handshake after rotation got %v, %v, want client-b", got, err)
+	}
+}
+
+func TestNewFailsOnMissingFiles(t *testing.T) {
+	if _, err := New(Options{CAFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Error("New with a missing CA file succeeded")
+	}
+}